@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApiKeyController interface {
+	Create(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	Revoke(ctx *gin.Context)
+}
+
+type ApiKeyControllerImpl struct {
+	ApiKeyService service.ApiKeyService
+}
+
+func NewApiKeyController(apiKeyService service.ApiKeyService) ApiKeyController {
+	return &ApiKeyControllerImpl{
+		ApiKeyService: apiKeyService,
+	}
+}
+
+func (c *ApiKeyControllerImpl) Create(ctx *gin.Context) {
+	apiKeyRequest := new(web.ApiKeyCreateRequest)
+	if err := ctx.ShouldBindJSON(apiKeyRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	apiKeyResponse, customErr := c.ApiKeyService.Create(ctx.Request.Context(), apiKeyRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusCreated,
+		Status: "OK",
+		Data:   apiKeyResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *ApiKeyControllerImpl) FindAll(ctx *gin.Context) {
+	apiKeyResponses, customErr := c.ApiKeyService.FindAll(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   apiKeyResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *ApiKeyControllerImpl) Revoke(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	customErr := c.ApiKeyService.Revoke(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "API key revoked successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditLogController interface {
+	FindAll(ctx *gin.Context)
+}
+
+type AuditLogControllerImpl struct {
+	AuditLogService service.AuditLogService
+}
+
+func NewAuditLogController(auditLogService service.AuditLogService) AuditLogController {
+	return &AuditLogControllerImpl{
+		AuditLogService: auditLogService,
+	}
+}
+
+func (c *AuditLogControllerImpl) FindAll(ctx *gin.Context) {
+	auditLogFilter := new(web.AuditLogFilter)
+	if err := ctx.ShouldBindQuery(auditLogFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	pageResponse, customErr := c.AuditLogService.FindAll(ctx.Request.Context(), auditLogFilter)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   pageResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthorController interface {
+	Create(ctx *gin.Context)
+	Find(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+}
+
+type AuthorControllerImpl struct {
+	AuthorService service.AuthorService
+}
+
+func NewAuthorController(authorService service.AuthorService) AuthorController {
+	return &AuthorControllerImpl{
+		AuthorService: authorService,
+	}
+}
+
+func (c *AuthorControllerImpl) Create(ctx *gin.Context) {
+	authorCreateRequest := new(web.AuthorCreate)
+	if err := ctx.ShouldBindJSON(authorCreateRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	authorResponse, customErr := c.AuthorService.Create(ctx.Request.Context(), authorCreateRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   authorResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *AuthorControllerImpl) Find(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	authorResponse, customErr := c.AuthorService.Find(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   authorResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *AuthorControllerImpl) Update(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	authorUpdateRequest := new(web.AuthorUpdate)
+	if err := ctx.ShouldBindJSON(authorUpdateRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+	authorUpdateRequest.Id = idInt
+
+	authorResponse, customErr := c.AuthorService.Update(ctx.Request.Context(), authorUpdateRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   authorResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *AuthorControllerImpl) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	customErr := c.AuthorService.Delete(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "Author deleted successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *AuthorControllerImpl) FindAll(ctx *gin.Context) {
+	authorResponses, customErr := c.AuthorService.FindAll(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   authorResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
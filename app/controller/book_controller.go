@@ -1,11 +1,15 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"kukuh/go-gin-library-project/app/service"
 	"kukuh/go-gin-library-project/app/web"
 	"kukuh/go-gin-library-project/response"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,8 +18,13 @@ type BookController interface {
 	Create(ctx *gin.Context)
 	Find(ctx *gin.Context)
 	Update(ctx *gin.Context)
+	Patch(ctx *gin.Context)
 	Delete(ctx *gin.Context)
 	FindAll(ctx *gin.Context)
+	UploadCover(ctx *gin.Context)
+	FindCover(ctx *gin.Context)
+	FindDeleted(ctx *gin.Context)
+	Restore(ctx *gin.Context)
 }
 
 type BookControllerImpl struct {
@@ -61,6 +70,13 @@ func (c *BookControllerImpl) Find(ctx *gin.Context) {
 		return
 	}
 
+	etag := bookETag(bookResponse.Version)
+	ctx.Header("ETag", etag)
+	if matchesETag(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
 	webResponse := response.WebResponse{
 		Code:   http.StatusOK,
 		Status: "OK",
@@ -80,8 +96,15 @@ func (c *BookControllerImpl) Update(ctx *gin.Context) {
 		return
 	}
 
+	version, customErr := parseIfMatch(ctx.GetHeader("If-Match"))
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
 	idInt, _ := strconv.Atoi(id)
 	bookUpdateRequest.Id = idInt
+	bookUpdateRequest.Version = version
 
 	bookResponse, customErr := c.BookService.Update(ctx.Request.Context(), bookUpdateRequest)
 	if customErr != nil {
@@ -89,6 +112,41 @@ func (c *BookControllerImpl) Update(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header("ETag", bookETag(bookResponse.Version))
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   bookResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BookControllerImpl) Patch(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	version, customErr := parseIfMatch(ctx.GetHeader("If-Match"))
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	patch, customErr := readMergePatch(ctx)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	bookResponse, customErr := c.BookService.Patch(ctx.Request.Context(), idInt, patch, version)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	ctx.Header("ETag", bookETag(bookResponse.Version))
+
 	webResponse := response.WebResponse{
 		Code:   http.StatusOK,
 		Status: "OK",
@@ -118,7 +176,14 @@ func (c *BookControllerImpl) Delete(ctx *gin.Context) {
 }
 
 func (c *BookControllerImpl) FindAll(ctx *gin.Context) {
-	bookResponses, customErr := c.BookService.FindAll(ctx.Request.Context())
+	bookFilter := new(web.BookFilter)
+	if err := ctx.ShouldBindQuery(bookFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	bookResponses, customErr := c.BookService.FindAll(ctx.Request.Context(), bookFilter)
 	if customErr != nil {
 		ctx.JSON(customErr.StatusCode, customErr)
 		return
@@ -132,3 +197,140 @@ func (c *BookControllerImpl) FindAll(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, webResponse)
 }
+
+func (c *BookControllerImpl) UploadCover(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	// MaxMultipartMemory only decides what spills to disk; this caps the
+	// request itself, leaving room for the multipart framing.
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, service.MaxCoverSize+1<<20)
+	fileHeader, err := ctx.FormFile("cover")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		customErr := response.PayloadTooLargeError()
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	if err != nil {
+		customErr := response.BadRequestError("Invalid cover file: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	if fileHeader.Size > service.MaxCoverSize {
+		customErr := response.PayloadTooLargeError()
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		customErr := response.BadRequestError("Invalid cover file: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, service.MaxCoverSize+1))
+	if err != nil {
+		customErr := response.BadRequestError("Invalid cover file: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	bookResponse, customErr := c.BookService.UploadCover(ctx.Request.Context(), idInt, data)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   bookResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BookControllerImpl) FindCover(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	data, contentType, customErr := c.BookService.FindCover(ctx.Request.Context(), idInt, ctx.Query("size"))
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+func (c *BookControllerImpl) FindDeleted(ctx *gin.Context) {
+	bookResponses, customErr := c.BookService.FindDeleted(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   bookResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BookControllerImpl) Restore(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	bookResponse, customErr := c.BookService.Restore(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   bookResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func bookETag(version int) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// matchesETag reports whether an If-None-Match header value matches etag.
+// Weak comparison is used, as RFC 9110 requires for If-None-Match.
+func matchesETag(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIfMatch returns the book version the client expects to overwrite.
+// A wildcard returns 0, which skips the version check.
+func parseIfMatch(header string) (int, *response.CustomError) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, response.PreconditionRequiredError("If-Match header is required")
+	}
+	if header == "*" {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(header, "\""))
+	if err != nil || version < 1 {
+		return 0, response.PreconditionFailedError("If-Match does not match the current version")
+	}
+	return version, nil
+}
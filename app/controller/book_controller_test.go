@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		version    int
+		statusCode int
+	}{
+		{"quoted version", `"3"`, 3, 0},
+		{"surrounding space", ` "12" `, 12, 0},
+		{"unquoted version", `7`, 7, 0},
+		{"wildcard", `*`, 0, 0},
+		{"missing", ``, 0, http.StatusPreconditionRequired},
+		{"blank", `  `, 0, http.StatusPreconditionRequired},
+		// If-Match uses strong comparison, so a weak tag never matches.
+		{"weak tag", `W/"3"`, 0, http.StatusPreconditionFailed},
+		{"list of tags", `"3", "4"`, 0, http.StatusPreconditionFailed},
+		{"zero", `"0"`, 0, http.StatusPreconditionFailed},
+		{"negative", `"-1"`, 0, http.StatusPreconditionFailed},
+		{"not a number", `"abc"`, 0, http.StatusPreconditionFailed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, customErr := parseIfMatch(test.header)
+			if test.statusCode != 0 {
+				if customErr == nil || customErr.StatusCode != test.statusCode {
+					t.Fatalf("parseIfMatch(%q) error = %+v, want status %d", test.header, customErr, test.statusCode)
+				}
+				return
+			}
+			if customErr != nil {
+				t.Fatalf("parseIfMatch(%q) error = %+v", test.header, customErr)
+			}
+			if version != test.version {
+				t.Errorf("parseIfMatch(%q) = %d, want %d", test.header, version, test.version)
+			}
+		})
+	}
+}
+
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"2", "3"`, true},
+		{`*`, true},
+		{`"4"`, false},
+		{``, false},
+	}
+	for _, test := range tests {
+		if got := matchesETag(test.header, bookETag(3)); got != test.want {
+			t.Errorf("matchesETag(%q) = %t, want %t", test.header, got, test.want)
+		}
+	}
+}
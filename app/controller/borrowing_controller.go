@@ -13,8 +13,13 @@ import (
 type BorrowingController interface {
 	Create(ctx *gin.Context)
 	Return(ctx *gin.Context)
+	Renew(ctx *gin.Context)
+	UpdateStatus(ctx *gin.Context)
 	Find(ctx *gin.Context)
 	FindAll(ctx *gin.Context)
+	FindOwn(ctx *gin.Context)
+	FindByUser(ctx *gin.Context)
+	FindLost(ctx *gin.Context)
 }
 
 type BorrowingControllerImpl struct {
@@ -99,11 +104,85 @@ func (c *BorrowingControllerImpl) Return(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, webResponse)
 }
 
+func (c *BorrowingControllerImpl) Renew(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	idInt, _ := strconv.Atoi(id)
+	userIdInt, _ := strconv.Atoi(userId)
+
+	borrowingResponse, customErr := c.BorrowingService.Renew(ctx.Request.Context(), idInt, userIdInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   borrowingResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BorrowingControllerImpl) UpdateStatus(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	statusRequest := new(web.BorrowingStatusRequest)
+	if err := ctx.ShouldBindJSON(statusRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+
+	borrowingResponse, customErr := c.BorrowingService.UpdateStatus(ctx.Request.Context(), idInt, statusRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   borrowingResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
 func (c *BorrowingControllerImpl) Find(ctx *gin.Context) {
 	id := ctx.Param("id")
+
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
 	idInt, _ := strconv.Atoi(id)
+	userIdInt, _ := strconv.Atoi(userId)
 
-	borrowingResponse, customErr := c.BorrowingService.Find(ctx.Request.Context(), idInt)
+	borrowingResponse, customErr := c.BorrowingService.Find(ctx.Request.Context(), idInt, userIdInt, ctx.GetString("authRole"), ctx.Query("expand"))
 	if customErr != nil {
 		ctx.JSON(customErr.StatusCode, customErr)
 		return
@@ -119,7 +198,7 @@ func (c *BorrowingControllerImpl) Find(ctx *gin.Context) {
 }
 
 func (c *BorrowingControllerImpl) FindAll(ctx *gin.Context) {
-	borrowingResponses, customErr := c.BorrowingService.FindAll(ctx.Request.Context())
+	borrowingResponses, customErr := c.BorrowingService.FindAll(ctx.Request.Context(), ctx.Query("expand"))
 	if customErr != nil {
 		ctx.JSON(customErr.StatusCode, customErr)
 		return
@@ -133,3 +212,91 @@ func (c *BorrowingControllerImpl) FindAll(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, webResponse)
 }
+
+func (c *BorrowingControllerImpl) FindOwn(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	borrowingFilter := new(web.BorrowingFilter)
+	if err := ctx.ShouldBindQuery(borrowingFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	userIdInt, _ := strconv.Atoi(userId)
+
+	pageResponse, customErr := c.BorrowingService.FindByUser(ctx.Request.Context(), userIdInt, borrowingFilter)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   pageResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BorrowingControllerImpl) FindByUser(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	borrowingFilter := new(web.BorrowingFilter)
+	if err := ctx.ShouldBindQuery(borrowingFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+
+	pageResponse, customErr := c.BorrowingService.FindByUser(ctx.Request.Context(), idInt, borrowingFilter)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   pageResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *BorrowingControllerImpl) FindLost(ctx *gin.Context) {
+	lostItemFilter := new(web.LostItemFilter)
+	if err := ctx.ShouldBindQuery(lostItemFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	pageResponse, customErr := c.BorrowingService.FindLost(ctx.Request.Context(), lostItemFilter)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   pageResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CirculationPolicyController interface {
+	Save(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+}
+
+type CirculationPolicyControllerImpl struct {
+	CirculationPolicyService service.CirculationPolicyService
+}
+
+func NewCirculationPolicyController(circulationPolicyService service.CirculationPolicyService) CirculationPolicyController {
+	return &CirculationPolicyControllerImpl{
+		CirculationPolicyService: circulationPolicyService,
+	}
+}
+
+func (c *CirculationPolicyControllerImpl) Save(ctx *gin.Context) {
+	policyRequest := new(web.CirculationPolicyRequest)
+	if err := ctx.ShouldBindJSON(policyRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	policyResponse, customErr := c.CirculationPolicyService.Save(ctx.Request.Context(), policyRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   policyResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *CirculationPolicyControllerImpl) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	customErr := c.CirculationPolicyService.Delete(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "Policy deleted successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *CirculationPolicyControllerImpl) FindAll(ctx *gin.Context) {
+	policyResponses, customErr := c.CirculationPolicyService.FindAll(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   policyResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
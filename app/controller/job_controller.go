@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobController interface {
+	FindAll(ctx *gin.Context)
+	FindRuns(ctx *gin.Context)
+	Trigger(ctx *gin.Context)
+}
+
+type JobControllerImpl struct {
+	JobService service.JobService
+}
+
+func NewJobController(jobService service.JobService) JobController {
+	return &JobControllerImpl{
+		JobService: jobService,
+	}
+}
+
+func (c *JobControllerImpl) FindAll(ctx *gin.Context) {
+	jobResponses, customErr := c.JobService.FindAll(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   jobResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *JobControllerImpl) FindRuns(ctx *gin.Context) {
+	jobRunFilter := new(web.JobRunFilter)
+	if err := ctx.ShouldBindQuery(jobRunFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	pageResponse, customErr := c.JobService.FindRuns(ctx.Request.Context(), jobRunFilter)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   pageResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *JobControllerImpl) Trigger(ctx *gin.Context) {
+	jobRunResponse, customErr := c.JobService.Trigger(ctx.Request.Context(), ctx.Param("name"))
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusAccepted,
+		Status: "OK",
+		Data:   jobRunResponse,
+	}
+
+	ctx.JSON(http.StatusAccepted, webResponse)
+}
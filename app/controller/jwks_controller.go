@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/helper/token"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JwksController interface {
+	Find(ctx *gin.Context)
+}
+
+type JwksControllerImpl struct {
+}
+
+func NewJwksController() JwksController {
+	return &JwksControllerImpl{}
+}
+
+// Find serves the public keys as a bare JWKS document rather than wrapped
+// in a WebResponse, since that is the shape JWT libraries fetch.
+func (c *JwksControllerImpl) Find(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, token.PublicKeys())
+}
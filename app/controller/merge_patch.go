@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"kukuh/go-gin-library-project/response"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxMergePatchSize = 1 << 20
+
+func readMergePatch(ctx *gin.Context) ([]byte, *response.CustomError) {
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+		return nil, response.UnsupportedMediaTypeError("Content-Type must be application/merge-patch+json")
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxMergePatchSize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, response.PayloadTooLargeError(fmt.Sprintf("Patch must not exceed %d bytes", maxMergePatchSize))
+	}
+	if err != nil {
+		return nil, response.BadRequestError("Invalid request body: " + err.Error())
+	}
+	return patch, nil
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController interface {
+	FindPreference(ctx *gin.Context)
+	UpdatePreference(ctx *gin.Context)
+}
+
+type NotificationControllerImpl struct {
+	NotificationService service.NotificationService
+}
+
+func NewNotificationController(notificationService service.NotificationService) NotificationController {
+	return &NotificationControllerImpl{
+		NotificationService: notificationService,
+	}
+}
+
+func (c *NotificationControllerImpl) FindPreference(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+
+	preferenceResponse, customErr := c.NotificationService.FindPreference(ctx.Request.Context(), userIdint)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   preferenceResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *NotificationControllerImpl) UpdatePreference(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	preferenceRequest := new(web.NotificationPreferenceRequest)
+	if err := ctx.ShouldBindJSON(preferenceRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+	preferenceRequest.UserId = userIdint
+
+	preferenceResponse, customErr := c.NotificationService.UpdatePreference(ctx.Request.Context(), preferenceRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   preferenceResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie ties a login to the browser that started it, so a
+// callback URL from someone else's login cannot be used to sign us in as
+// them.
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/api/oidc"
+)
+
+type OidcController interface {
+	Login(ctx *gin.Context)
+	Callback(ctx *gin.Context)
+}
+
+type OidcControllerImpl struct {
+	OidcService service.OidcService
+}
+
+func NewOidcController(oidcService service.OidcService) OidcController {
+	return &OidcControllerImpl{
+		OidcService: oidcService,
+	}
+}
+
+func (c *OidcControllerImpl) Login(ctx *gin.Context) {
+	startResponse, customErr := c.OidcService.Start(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcStateCookie, startResponse.State, int(service.OidcLoginTTL.Seconds()), oidcCookiePath, "", ctx.Request.TLS != nil, true)
+	ctx.Redirect(http.StatusFound, startResponse.AuthorizationUrl)
+}
+
+func (c *OidcControllerImpl) Callback(ctx *gin.Context) {
+	callbackRequest := new(web.OidcCallbackRequest)
+	if err := ctx.ShouldBindQuery(callbackRequest); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	callbackRequest.StateCookie, _ = ctx.Cookie(oidcStateCookie)
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcStateCookie, "", -1, oidcCookiePath, "", ctx.Request.TLS != nil, true)
+
+	loginResponse, customErr := c.OidcService.Callback(ctx.Request.Context(), callbackRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   loginResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
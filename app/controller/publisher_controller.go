@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PublisherController interface {
+	Create(ctx *gin.Context)
+	Find(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+}
+
+type PublisherControllerImpl struct {
+	PublisherService service.PublisherService
+}
+
+func NewPublisherController(publisherService service.PublisherService) PublisherController {
+	return &PublisherControllerImpl{
+		PublisherService: publisherService,
+	}
+}
+
+func (c *PublisherControllerImpl) Create(ctx *gin.Context) {
+	publisherCreateRequest := new(web.PublisherCreate)
+	if err := ctx.ShouldBindJSON(publisherCreateRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	publisherResponse, customErr := c.PublisherService.Create(ctx.Request.Context(), publisherCreateRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   publisherResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *PublisherControllerImpl) Find(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	publisherResponse, customErr := c.PublisherService.Find(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   publisherResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *PublisherControllerImpl) Update(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	publisherUpdateRequest := new(web.PublisherUpdate)
+	if err := ctx.ShouldBindJSON(publisherUpdateRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+	publisherUpdateRequest.Id = idInt
+
+	publisherResponse, customErr := c.PublisherService.Update(ctx.Request.Context(), publisherUpdateRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   publisherResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *PublisherControllerImpl) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	customErr := c.PublisherService.Delete(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "Publisher deleted successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *PublisherControllerImpl) FindAll(ctx *gin.Context) {
+	publisherResponses, customErr := c.PublisherService.FindAll(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   publisherResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
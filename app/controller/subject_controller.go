@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SubjectController interface {
+	Create(ctx *gin.Context)
+	Find(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+}
+
+type SubjectControllerImpl struct {
+	SubjectService service.SubjectService
+}
+
+func NewSubjectController(subjectService service.SubjectService) SubjectController {
+	return &SubjectControllerImpl{
+		SubjectService: subjectService,
+	}
+}
+
+func (c *SubjectControllerImpl) Create(ctx *gin.Context) {
+	subjectCreateRequest := new(web.SubjectCreate)
+	if err := ctx.ShouldBindJSON(subjectCreateRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	subjectResponse, customErr := c.SubjectService.Create(ctx.Request.Context(), subjectCreateRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   subjectResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *SubjectControllerImpl) Find(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	subjectResponse, customErr := c.SubjectService.Find(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   subjectResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *SubjectControllerImpl) Update(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	subjectUpdateRequest := new(web.SubjectUpdate)
+	if err := ctx.ShouldBindJSON(subjectUpdateRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+	subjectUpdateRequest.Id = idInt
+
+	subjectResponse, customErr := c.SubjectService.Update(ctx.Request.Context(), subjectUpdateRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   subjectResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *SubjectControllerImpl) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	customErr := c.SubjectService.Delete(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "Subject deleted successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *SubjectControllerImpl) FindAll(ctx *gin.Context) {
+	subjectResponses, customErr := c.SubjectService.FindAll(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   subjectResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController interface {
+	Enroll(ctx *gin.Context)
+	Confirm(ctx *gin.Context)
+	Disable(ctx *gin.Context)
+	RegenerateRecoveryCodes(ctx *gin.Context)
+}
+
+type TwoFactorControllerImpl struct {
+	TwoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorController(twoFactorService service.TwoFactorService) TwoFactorController {
+	return &TwoFactorControllerImpl{
+		TwoFactorService: twoFactorService,
+	}
+}
+
+func (c *TwoFactorControllerImpl) Enroll(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+
+	enrollResponse, customErr := c.TwoFactorService.Enroll(ctx.Request.Context(), userIdint)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   enrollResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *TwoFactorControllerImpl) Confirm(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+
+	codeRequest := new(web.TwoFactorCodeRequest)
+	if err := ctx.ShouldBindJSON(codeRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	confirmResponse, customErr := c.TwoFactorService.Confirm(ctx.Request.Context(), userIdint, codeRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   confirmResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *TwoFactorControllerImpl) Disable(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+
+	disableRequest := new(web.TwoFactorDisableRequest)
+	if err := ctx.ShouldBindJSON(disableRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	disableRequest.IpAddress = ctx.ClientIP()
+
+	customErr := c.TwoFactorService.Disable(ctx.Request.Context(), userIdint, disableRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "Two-factor authentication disabled successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *TwoFactorControllerImpl) RegenerateRecoveryCodes(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+
+	codeRequest := new(web.TwoFactorCodeRequest)
+	if err := ctx.ShouldBindJSON(codeRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	codeRequest.IpAddress = ctx.ClientIP()
+
+	recoveryCodesResponse, customErr := c.TwoFactorService.RegenerateRecoveryCodes(ctx.Request.Context(), userIdint, codeRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   recoveryCodesResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
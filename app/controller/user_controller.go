@@ -13,8 +13,26 @@ import (
 type UserController interface {
 	Register(ctx *gin.Context)
 	Login(ctx *gin.Context)
+	LoginTwoFactor(ctx *gin.Context)
+	VerifyEmail(ctx *gin.Context)
+	ResendVerification(ctx *gin.Context)
+	ForgotPassword(ctx *gin.Context)
+	ResetPassword(ctx *gin.Context)
+	FindOwn(ctx *gin.Context)
 	UpdateUserOwn(ctx *gin.Context)
+	ChangePassword(ctx *gin.Context)
+	ChangeEmail(ctx *gin.Context)
+	ConfirmEmailChange(ctx *gin.Context)
+	PatchUserOwn(ctx *gin.Context)
 	DeleteUser(ctx *gin.Context)
+	FindDeleted(ctx *gin.Context)
+	Restore(ctx *gin.Context)
+	Unlock(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	FindById(ctx *gin.Context)
+	Create(ctx *gin.Context)
+	UpdateAccount(ctx *gin.Context)
+	UpdateStatus(ctx *gin.Context)
 }
 
 type UserControllerImpl struct {
@@ -57,6 +75,7 @@ func (c *UserControllerImpl) Login(ctx *gin.Context) {
 		ctx.JSON(customErr.StatusCode, customErr)
 		return
 	}
+	loginRequest.IpAddress = ctx.ClientIP()
 
 	loginResponse, customErr := c.UserService.Login(ctx.Request.Context(), loginRequest)
 	if customErr != nil {
@@ -72,6 +91,152 @@ func (c *UserControllerImpl) Login(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, webResponse)
 }
 
+func (c *UserControllerImpl) LoginTwoFactor(ctx *gin.Context) {
+	loginRequest := new(web.LoginTwoFactorRequest)
+	if err := ctx.ShouldBindJSON(loginRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	loginRequest.IpAddress = ctx.ClientIP()
+
+	loginResponse, customErr := c.UserService.LoginTwoFactor(ctx.Request.Context(), loginRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   loginResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) VerifyEmail(ctx *gin.Context) {
+	verifyRequest := new(web.VerifyEmailRequest)
+	if err := ctx.ShouldBindQuery(verifyRequest); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	userResponse, customErr := c.UserService.VerifyEmail(ctx.Request.Context(), verifyRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) ResendVerification(ctx *gin.Context) {
+	resendRequest := new(web.ResendVerificationRequest)
+	if err := ctx.ShouldBindJSON(resendRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	customErr := c.UserService.ResendVerification(ctx.Request.Context(), resendRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "If the account is awaiting verification, a new link has been sent"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) ForgotPassword(ctx *gin.Context) {
+	forgotRequest := new(web.ForgotPasswordRequest)
+	if err := ctx.ShouldBindJSON(forgotRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	forgotRequest.IpAddress = ctx.ClientIP()
+
+	customErr := c.UserService.ForgotPassword(ctx.Request.Context(), forgotRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "If the email is registered, a password reset link has been sent"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) ResetPassword(ctx *gin.Context) {
+	resetRequest := new(web.ResetPasswordRequest)
+	if err := ctx.ShouldBindJSON(resetRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	customErr := c.UserService.ResetPassword(ctx.Request.Context(), resetRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "Password has been reset, please log in again"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) FindOwn(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+
+	userResponse, customErr := c.UserService.FindOwn(ctx.Request.Context(), userIdint)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
 func (c *UserControllerImpl) UpdateUserOwn(ctx *gin.Context) {
 	authId, exists := ctx.Get("authId")
 	if !exists {
@@ -109,6 +274,139 @@ func (c *UserControllerImpl) UpdateUserOwn(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, webResponse)
 }
 
+func (c *UserControllerImpl) ChangePassword(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	passwordRequest := new(web.ChangePasswordRequest)
+	if err := ctx.ShouldBindJSON(passwordRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+	passwordRequest.UserId = userIdint
+	passwordRequest.IpAddress = ctx.ClientIP()
+
+	passwordResponse, customErr := c.UserService.ChangePassword(ctx.Request.Context(), passwordRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   passwordResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) ChangeEmail(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	emailRequest := new(web.ChangeEmailRequest)
+	if err := ctx.ShouldBindJSON(emailRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+	emailRequest.UserId = userIdint
+	emailRequest.IpAddress = ctx.ClientIP()
+
+	customErr := c.UserService.ChangeEmail(ctx.Request.Context(), emailRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "A confirmation link has been sent to the new email address"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) ConfirmEmailChange(ctx *gin.Context) {
+	confirmRequest := new(web.VerifyEmailRequest)
+	if err := ctx.ShouldBindQuery(confirmRequest); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	userResponse, customErr := c.UserService.ConfirmEmailChange(ctx.Request.Context(), confirmRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) PatchUserOwn(ctx *gin.Context) {
+	authId, exists := ctx.Get("authId")
+	if !exists {
+		customErr := response.UnauthorizedError("Authentication ID not found")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userId, ok := authId.(string)
+	if !ok {
+		customErr := response.UnauthorizedError("Invalid authentication ID format")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	patch, customErr := readMergePatch(ctx)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	userIdint, _ := strconv.Atoi(userId)
+
+	userResponse, customErr := c.UserService.PatchUserOwn(ctx.Request.Context(), userIdint, patch)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
 func (c *UserControllerImpl) DeleteUser(ctx *gin.Context) {
 	authId, exists := ctx.Get("authId")
 	if !exists {
@@ -139,3 +437,176 @@ func (c *UserControllerImpl) DeleteUser(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, webResponse)
 }
+
+func (c *UserControllerImpl) FindDeleted(ctx *gin.Context) {
+	userResponses, customErr := c.UserService.FindDeleted(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) Restore(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	userResponse, customErr := c.UserService.Restore(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) Unlock(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	customErr := c.UserService.Unlock(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "User unlocked successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) FindAll(ctx *gin.Context) {
+	userFilter := new(web.UserFilter)
+	if err := ctx.ShouldBindQuery(userFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	pageResponse, customErr := c.UserService.FindAll(ctx.Request.Context(), userFilter)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   pageResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	userResponse, customErr := c.UserService.FindById(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) Create(ctx *gin.Context) {
+	userCreateRequest := new(web.CreateUserRequest)
+	if err := ctx.ShouldBindJSON(userCreateRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	userResponse, customErr := c.UserService.Create(ctx.Request.Context(), userCreateRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) UpdateAccount(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	accountRequest := new(web.UpdateAccountRequest)
+	if err := ctx.ShouldBindJSON(accountRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body")
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+
+	userResponse, customErr := c.UserService.UpdateAccount(ctx.Request.Context(), idInt, accountRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *UserControllerImpl) UpdateStatus(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	statusRequest := new(web.UserStatusRequest)
+	if err := ctx.ShouldBindJSON(statusRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+
+	userResponse, customErr := c.UserService.UpdateStatus(ctx.Request.Context(), idInt, statusRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   userResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
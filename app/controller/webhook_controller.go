@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"kukuh/go-gin-library-project/app/service"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController interface {
+	Create(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	FindDeliveries(ctx *gin.Context)
+	Redeliver(ctx *gin.Context)
+}
+
+type WebhookControllerImpl struct {
+	WebhookService service.WebhookService
+}
+
+func NewWebhookController(webhookService service.WebhookService) WebhookController {
+	return &WebhookControllerImpl{
+		WebhookService: webhookService,
+	}
+}
+
+func (c *WebhookControllerImpl) Create(ctx *gin.Context) {
+	subscriptionRequest := new(web.WebhookSubscriptionRequest)
+	if err := ctx.ShouldBindJSON(subscriptionRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	subscriptionResponse, customErr := c.WebhookService.Create(ctx.Request.Context(), subscriptionRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusCreated,
+		Status: "OK",
+		Data:   subscriptionResponse,
+	}
+
+	ctx.JSON(http.StatusCreated, webResponse)
+}
+
+func (c *WebhookControllerImpl) Update(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	subscriptionRequest := new(web.WebhookSubscriptionRequest)
+	if err := ctx.ShouldBindJSON(subscriptionRequest); err != nil {
+		customErr := response.BadRequestError("Invalid request body: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	idInt, _ := strconv.Atoi(id)
+	subscriptionRequest.Id = idInt
+
+	subscriptionResponse, customErr := c.WebhookService.Update(ctx.Request.Context(), subscriptionRequest)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   subscriptionResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	customErr := c.WebhookService.Delete(ctx.Request.Context(), idInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   gin.H{"message": "Webhook deleted successfully"},
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) FindAll(ctx *gin.Context) {
+	subscriptionResponses, customErr := c.WebhookService.FindAll(ctx.Request.Context())
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   subscriptionResponses,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) FindDeliveries(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+
+	deliveryFilter := new(web.WebhookDeliveryFilter)
+	if err := ctx.ShouldBindQuery(deliveryFilter); err != nil {
+		customErr := response.BadRequestError("Invalid query: " + err.Error())
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	pageResponse, customErr := c.WebhookService.FindDeliveries(ctx.Request.Context(), idInt, deliveryFilter)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   pageResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
+
+func (c *WebhookControllerImpl) Redeliver(ctx *gin.Context) {
+	id := ctx.Param("id")
+	idInt, _ := strconv.Atoi(id)
+	deliveryId := ctx.Param("deliveryId")
+	deliveryIdInt, _ := strconv.Atoi(deliveryId)
+
+	deliveryResponse, customErr := c.WebhookService.Redeliver(ctx.Request.Context(), idInt, deliveryIdInt)
+	if customErr != nil {
+		ctx.JSON(customErr.StatusCode, customErr)
+		return
+	}
+
+	webResponse := response.WebResponse{
+		Code:   http.StatusOK,
+		Status: "OK",
+		Data:   deliveryResponse,
+	}
+
+	ctx.JSON(http.StatusOK, webResponse)
+}
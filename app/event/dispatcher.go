@@ -0,0 +1,236 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/helper"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBatchSize = 100
+	maxAttempts      = 10
+	backoffBase      = 5 * time.Second
+	backoffMax       = time.Hour
+)
+
+// Subscriber reacts to domain events. HandleEvent runs inside a savepoint of
+// the dispatcher's transaction, so writes made with tx commit together with
+// the record that the subscriber handled the event. Side effects outside
+// the database belong in helper.AfterCommit: they run once the event's
+// transaction has committed, and when one fails the event is retried for
+// that subscriber, so they should be idempotent.
+type Subscriber interface {
+	HandleEvent(ctx context.Context, tx *gorm.DB, event model.OutboxEvent) error
+}
+
+type subscription struct {
+	name       string
+	subscriber Subscriber
+}
+
+// Dispatcher delivers outbox events to subscribers. Each subscriber is
+// tracked separately, so when one fails only that one is retried, with
+// exponential backoff. Several dispatchers may poll the same outbox; rows
+// are locked with SKIP LOCKED so each event is handled by one of them.
+type Dispatcher struct {
+	OutboxRepository repository.OutboxRepository
+	DB               *gorm.DB
+	BatchSize        int
+	subscriptions    []subscription
+}
+
+func NewDispatcher(outboxRepository repository.OutboxRepository, DB *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		OutboxRepository: outboxRepository,
+		DB:               DB,
+		BatchSize:        defaultBatchSize,
+	}
+}
+
+// Subscribe registers subscriber under name. The name is stored with each
+// handled event, so it must stay stable across releases.
+func (d *Dispatcher) Subscribe(name string, subscriber Subscriber) {
+	d.subscriptions = append(d.subscriptions, subscription{name: name, subscriber: subscriber})
+}
+
+// Start dispatches pending events every interval until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			dispatched, err := d.DispatchPending(ctx)
+			if err != nil {
+				log.Printf("event dispatcher: %v", err)
+			}
+			if err != nil || dispatched < d.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// DispatchPending handles up to one batch of due events and returns how
+// many it handled. Each event is locked and handled in its own transaction,
+// so a slow subscriber holds up one event rather than the whole batch.
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	dispatched := 0
+	for dispatched < d.BatchSize {
+		found, err := d.dispatchNext(ctx)
+		if err != nil {
+			return dispatched, err
+		}
+		if !found {
+			break
+		}
+		dispatched++
+	}
+	return dispatched, nil
+}
+
+// dispatchNext handles the next due event, if any, and then runs the work
+// its subscribers deferred until after commit.
+func (d *Dispatcher) dispatchNext(ctx context.Context) (bool, error) {
+	var events []model.OutboxEvent
+	hooks := map[string]*helper.AfterCommitHooks{}
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		err := d.OutboxRepository.FindPending(tx, &events, time.Now(), 1)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		return d.dispatch(ctx, tx, &events[0], hooks)
+	})
+	if err != nil || len(events) == 0 {
+		return false, err
+	}
+
+	event := &events[0]
+	var failures []string
+	for _, s := range d.subscriptions {
+		subscriberHooks, ok := hooks[s.name]
+		if !ok {
+			continue
+		}
+		for _, fn := range subscriberHooks.Funcs {
+			err := fn(d.eventContext(ctx, event))
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", s.name, err))
+				err = d.reopen(event, s.name)
+				if err != nil {
+					return true, err
+				}
+				break
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return true, d.retry(event, failures)
+	}
+	return true, nil
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, tx *gorm.DB, event *model.OutboxEvent, hooks map[string]*helper.AfterCommitHooks) error {
+	var handled []string
+	err := d.OutboxRepository.FindHandled(tx, &handled, event.Id)
+	if err != nil {
+		return err
+	}
+
+	ctx = d.eventContext(ctx, event)
+
+	var failures []string
+	for _, s := range d.subscriptions {
+		if slices.Contains(handled, s.name) {
+			continue
+		}
+		subscriberHooks := &helper.AfterCommitHooks{}
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			err := s.subscriber.HandleEvent(helper.WithAfterCommit(ctx, subscriberHooks), tx, *event)
+			if err != nil {
+				return err
+			}
+			return d.OutboxRepository.MarkHandled(tx, event.Id, s.name, time.Now())
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.name, err))
+			continue
+		}
+		if len(subscriberHooks.Funcs) > 0 {
+			hooks[s.name] = subscriberHooks
+		}
+	}
+
+	event.Attempts++
+	return d.OutboxRepository.Update(tx, settle(event, failures))
+}
+
+// reopen forgets that subscriber handled event after its deferred work
+// failed, so the next attempt hands the event to it again.
+func (d *Dispatcher) reopen(event *model.OutboxEvent, subscriber string) error {
+	return d.OutboxRepository.UnmarkHandled(d.DB, event.Id, subscriber)
+}
+
+// retry reschedules event after deferred work failed. The attempt was
+// already counted when the event was handled.
+func (d *Dispatcher) retry(event *model.OutboxEvent, failures []string) error {
+	event.DispatchedAt = nil
+	if event.Status == model.OutboxStatusDispatched {
+		event.Status = model.OutboxStatusPending
+	}
+	if event.LastError != "" {
+		failures = append([]string{event.LastError}, failures...)
+	}
+	return d.OutboxRepository.Update(d.DB, settle(event, failures))
+}
+
+// eventContext carries the actor and request of the change that raised the
+// event, so anything subscribers audit is attributed to it.
+func (d *Dispatcher) eventContext(ctx context.Context, event *model.OutboxEvent) context.Context {
+	if event.ActorId != nil {
+		ctx = helper.WithAuthId(ctx, strconv.Itoa(*event.ActorId))
+	}
+	return helper.WithRequestId(ctx, event.RequestId)
+}
+
+// settle records the outcome of an attempt on event: dispatched when nothing
+// failed, otherwise retried with backoff until maxAttempts is reached.
+func settle(event *model.OutboxEvent, failures []string) *model.OutboxEvent {
+	now := time.Now()
+	switch {
+	case len(failures) == 0:
+		event.Status = model.OutboxStatusDispatched
+		event.LastError = ""
+		event.DispatchedAt = &now
+	case event.Attempts >= maxAttempts:
+		event.Status = model.OutboxStatusFailed
+		event.LastError = strings.Join(failures, "; ")
+	default:
+		event.LastError = strings.Join(failures, "; ")
+		event.NextAttemptAt = now.Add(backoff(event.Attempts))
+	}
+	return event
+}
+
+func backoff(attempts int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempts && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, backoffMax)
+}
@@ -0,0 +1,34 @@
+package job
+
+import (
+	"context"
+	"kukuh/go-gin-library-project/app/service"
+	"log"
+	"time"
+)
+
+type NotificationJob struct {
+	NotificationService service.NotificationService
+}
+
+func NewNotificationJob(notificationService service.NotificationService) *NotificationJob {
+	return &NotificationJob{
+		NotificationService: notificationService,
+	}
+}
+
+// Run sends due-soon reminders and overdue notices. Each is sent once per
+// loan and due date, so running the job often does not repeat them.
+func (j *NotificationJob) Run(ctx context.Context) error {
+	dueSoon, customErr := j.NotificationService.SendDueSoon(ctx, time.Now())
+	if customErr != nil {
+		return customErr
+	}
+	overdue, customErr := j.NotificationService.SendOverdue(ctx)
+	if customErr != nil {
+		return customErr
+	}
+
+	log.Printf("notification job: sent %d due-soon and %d overdue notifications", dueSoon, overdue)
+	return nil
+}
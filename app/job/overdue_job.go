@@ -0,0 +1,29 @@
+package job
+
+import (
+	"context"
+	"kukuh/go-gin-library-project/app/service"
+	"log"
+	"time"
+)
+
+type OverdueJob struct {
+	BorrowingService service.BorrowingService
+}
+
+func NewOverdueJob(borrowingService service.BorrowingService) *OverdueJob {
+	return &OverdueJob{
+		BorrowingService: borrowingService,
+	}
+}
+
+// Run marks open loans that are past their due date as overdue.
+func (j *OverdueJob) Run(ctx context.Context) error {
+	marked, customErr := j.BorrowingService.MarkOverdue(ctx, time.Now())
+	if customErr != nil {
+		return customErr
+	}
+
+	log.Printf("overdue job: marked %d borrowings overdue", marked)
+	return nil
+}
@@ -0,0 +1,48 @@
+package job
+
+import (
+	"context"
+	"kukuh/go-gin-library-project/app/service"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultRetentionDays = 30
+
+type PurgeJob struct {
+	BookService service.BookService
+	UserService service.UserService
+	Retention   time.Duration
+}
+
+func NewPurgeJob(bookService service.BookService, userService service.UserService) *PurgeJob {
+	retentionDays, err := strconv.Atoi(os.Getenv("SOFT_DELETE_RETENTION_DAYS"))
+	if err != nil || retentionDays <= 0 {
+		retentionDays = defaultRetentionDays
+	}
+	return &PurgeJob{
+		BookService: bookService,
+		UserService: userService,
+		Retention:   time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+// Run permanently removes books and users that have been soft deleted for
+// longer than the retention period.
+func (j *PurgeJob) Run(ctx context.Context) error {
+	deletedBefore := time.Now().Add(-j.Retention)
+
+	purgedBooks, customErr := j.BookService.Purge(ctx, deletedBefore)
+	if customErr != nil {
+		return customErr
+	}
+	purgedUsers, customErr := j.UserService.Purge(ctx, deletedBefore)
+	if customErr != nil {
+		return customErr
+	}
+
+	log.Printf("purge job: removed %d books and %d users deleted before %s", purgedBooks, purgedUsers, deletedBefore.Format(time.RFC3339))
+	return nil
+}
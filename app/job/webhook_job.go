@@ -0,0 +1,31 @@
+package job
+
+import (
+	"context"
+	"kukuh/go-gin-library-project/app/service"
+	"log"
+	"time"
+)
+
+type WebhookJob struct {
+	WebhookService service.WebhookService
+}
+
+func NewWebhookJob(webhookService service.WebhookService) *WebhookJob {
+	return &WebhookJob{
+		WebhookService: webhookService,
+	}
+}
+
+// Run attempts the webhook deliveries that are due, including retries.
+func (j *WebhookJob) Run(ctx context.Context) error {
+	attempted, customErr := j.WebhookService.DeliverPending(ctx, time.Now())
+	if customErr != nil {
+		return customErr
+	}
+
+	if attempted > 0 {
+		log.Printf("webhook job: attempted %d deliveries", attempted)
+	}
+	return nil
+}
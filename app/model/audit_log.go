@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+const (
+	AuditActionCreate       = "create"
+	AuditActionUpdate       = "update"
+	AuditActionDelete       = "delete"
+	AuditActionRestore      = "restore"
+	AuditActionPurge        = "purge"
+	AuditActionBorrow       = "borrow"
+	AuditActionReturn       = "return"
+	AuditActionRenew        = "renew"
+	AuditActionLoginFailed  = "login_failed"
+	AuditActionUnlock       = "unlock"
+	AuditActionRevoke       = "revoke"
+	AuditActionLinkIdentity = "link_identity"
+)
+
+const (
+	AuditEntityBook      = "book"
+	AuditEntityAuthor    = "author"
+	AuditEntityPublisher = "publisher"
+	AuditEntitySubject   = "subject"
+	AuditEntityUser      = "user"
+	AuditEntityBorrowing = "borrowing"
+	AuditEntityApiKey    = "api_key"
+)
+
+type AuditLog struct {
+	Id        int
+	ActorId   *int
+	Action    string
+	Entity    string
+	EntityId  int
+	Changes   string
+	RequestId string
+	CreatedAt time.Time
+}
+
+type AuditLogFilter struct {
+	ActorId  int
+	Action   string
+	Entity   string
+	EntityId int
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type Author struct {
+	Id        int
+	Name      string
+	Bio       string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type BookAuthor struct {
+	BookId int
+	Author
+}
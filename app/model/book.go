@@ -2,13 +2,27 @@ package model
 
 import "time"
 
+const BookCategoryGeneral = "general"
+
 type Book struct {
 	Id              int
 	Title           string
-	Author          string
 	Isbn            string
+	Category        string
 	PublicationYear int
 	Quantity        int
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	// ReplacementCostCents is charged to the patron when a copy is lost or
+	// returned damaged.
+	ReplacementCostCents int64
+	CoverKey             string
+	Version              int
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	DeletedAt            *time.Time
+}
+
+type BookFilter struct {
+	AuthorId    int
+	PublisherId int
+	SubjectId   int
 }
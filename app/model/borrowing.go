@@ -1,23 +1,99 @@
 package model
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+type BorrowingStatus string
+
+const (
+	BorrowingStatusBorrowed        BorrowingStatus = "borrowed"
+	BorrowingStatusOverdue         BorrowingStatus = "overdue"
+	BorrowingStatusReturned        BorrowingStatus = "returned"
+	BorrowingStatusLateReturned    BorrowingStatus = "late_returned"
+	BorrowingStatusLost            BorrowingStatus = "lost"
+	BorrowingStatusClaimedReturned BorrowingStatus = "claimed_returned"
+	BorrowingStatusDamaged         BorrowingStatus = "damaged"
+)
+
+// borrowingTransitions lists the statuses each status may move to. Returned,
+// late returned and damaged loans are final; a lost or claimed returned copy
+// that turns up is checked in like any other return.
+var borrowingTransitions = map[BorrowingStatus][]BorrowingStatus{
+	BorrowingStatusBorrowed: {
+		BorrowingStatusOverdue,
+		BorrowingStatusReturned,
+		BorrowingStatusLateReturned,
+		BorrowingStatusLost,
+		BorrowingStatusClaimedReturned,
+		BorrowingStatusDamaged,
+	},
+	BorrowingStatusOverdue: {
+		BorrowingStatusBorrowed,
+		BorrowingStatusLateReturned,
+		BorrowingStatusLost,
+		BorrowingStatusClaimedReturned,
+		BorrowingStatusDamaged,
+	},
+	BorrowingStatusClaimedReturned: {
+		BorrowingStatusReturned,
+		BorrowingStatusLateReturned,
+		BorrowingStatusLost,
+		BorrowingStatusDamaged,
+	},
+	BorrowingStatusLost: {
+		BorrowingStatusReturned,
+		BorrowingStatusLateReturned,
+		BorrowingStatusDamaged,
+	},
+}
+
+func (s BorrowingStatus) CanTransitionTo(next BorrowingStatus) bool {
+	return slices.Contains(borrowingTransitions[s], next)
+}
+
+// IsReturned reports whether the copy has been checked in. Damaged copies
+// are checked in but do not go back into circulation.
+func (s BorrowingStatus) IsReturned() bool {
+	return s == BorrowingStatusReturned || s == BorrowingStatusLateReturned || s == BorrowingStatusDamaged
+}
+
+// ChargesReplacement reports whether moving to the status bills the patron
+// for the copy.
+func (s BorrowingStatus) ChargesReplacement() bool {
+	return s == BorrowingStatusLost || s == BorrowingStatusDamaged
+}
+
+// IsOpen reports whether the patron still has the copy on loan.
+func (s BorrowingStatus) IsOpen() bool {
+	return s == BorrowingStatusBorrowed || s == BorrowingStatusOverdue
+}
 
 type Borrowing struct {
-	Id         int
-	UserId     int
-	BookId     int
-	BorrowDate time.Time
-	DueDate    time.Time
-	Status     string
-	ReturnDate time.Time
+	Id           int
+	UserId       int
+	BookId       int
+	BorrowDate   time.Time
+	DueDate      time.Time
+	Status       BorrowingStatus
+	ReturnDate   *time.Time
+	RenewalCount int
+}
+
+type BorrowingFilter struct {
+	UserId int
+	Status BorrowingStatus
+	Active *bool
+	Limit  int
+	Offset int
 }
 
+// BorrowingJoin is a borrowing read together with the book and borrower it
+// refers to.
 type BorrowingJoin struct {
-	Id         int
-	UserId     string
-	BookId     string
-	BorrowDate time.Time
-	DueDate    time.Time
-	Status     string
-	ReturnDate time.Time
+	Borrowing
+	BookTitle string
+	BookIsbn  string
+	UserName  string
 }
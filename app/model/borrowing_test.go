@@ -0,0 +1,105 @@
+package model
+
+import "testing"
+
+var borrowingStatuses = []BorrowingStatus{
+	BorrowingStatusBorrowed,
+	BorrowingStatusOverdue,
+	BorrowingStatusReturned,
+	BorrowingStatusLateReturned,
+	BorrowingStatusLost,
+	BorrowingStatusClaimedReturned,
+	BorrowingStatusDamaged,
+}
+
+func TestBorrowingStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from BorrowingStatus
+		to   BorrowingStatus
+		ok   bool
+	}{
+		{BorrowingStatusBorrowed, BorrowingStatusOverdue, true},
+		{BorrowingStatusBorrowed, BorrowingStatusReturned, true},
+		{BorrowingStatusBorrowed, BorrowingStatusLost, true},
+		{BorrowingStatusBorrowed, BorrowingStatusBorrowed, false},
+		{BorrowingStatusOverdue, BorrowingStatusBorrowed, true},
+		{BorrowingStatusOverdue, BorrowingStatusLateReturned, true},
+		// An overdue copy coming back is always a late return.
+		{BorrowingStatusOverdue, BorrowingStatusReturned, false},
+		{BorrowingStatusClaimedReturned, BorrowingStatusReturned, true},
+		{BorrowingStatusClaimedReturned, BorrowingStatusLost, true},
+		{BorrowingStatusClaimedReturned, BorrowingStatusBorrowed, false},
+		{BorrowingStatusLost, BorrowingStatusReturned, true},
+		{BorrowingStatusLost, BorrowingStatusDamaged, true},
+		{BorrowingStatusLost, BorrowingStatusBorrowed, false},
+		{BorrowingStatusLost, BorrowingStatusClaimedReturned, false},
+		{BorrowingStatus("unknown"), BorrowingStatusReturned, false},
+		{BorrowingStatusBorrowed, BorrowingStatus("unknown"), false},
+	}
+	for _, test := range tests {
+		if got := test.from.CanTransitionTo(test.to); got != test.ok {
+			t.Errorf("%s -> %s = %t, want %t", test.from, test.to, got, test.ok)
+		}
+	}
+}
+
+func TestReturnedStatusesAreFinal(t *testing.T) {
+	for _, from := range borrowingStatuses {
+		if !from.IsReturned() {
+			continue
+		}
+		for _, to := range borrowingStatuses {
+			if from.CanTransitionTo(to) {
+				t.Errorf("returned status %s may move to %s", from, to)
+			}
+		}
+	}
+}
+
+func TestBorrowingTransitionsTargetKnownStatuses(t *testing.T) {
+	known := map[BorrowingStatus]bool{}
+	for _, status := range borrowingStatuses {
+		known[status] = true
+	}
+	for from, targets := range borrowingTransitions {
+		if !known[from] {
+			t.Errorf("transitions from unknown status %s", from)
+		}
+		for _, to := range targets {
+			if !known[to] {
+				t.Errorf("%s may move to unknown status %s", from, to)
+			}
+			if to == from {
+				t.Errorf("%s may move to itself", from)
+			}
+		}
+	}
+}
+
+func TestBorrowingStatusPredicates(t *testing.T) {
+	tests := []struct {
+		status             BorrowingStatus
+		open               bool
+		returned           bool
+		chargesReplacement bool
+	}{
+		{BorrowingStatusBorrowed, true, false, false},
+		{BorrowingStatusOverdue, true, false, false},
+		{BorrowingStatusReturned, false, true, false},
+		{BorrowingStatusLateReturned, false, true, false},
+		{BorrowingStatusLost, false, false, true},
+		{BorrowingStatusClaimedReturned, false, false, false},
+		{BorrowingStatusDamaged, false, true, true},
+	}
+	for _, test := range tests {
+		if got := test.status.IsOpen(); got != test.open {
+			t.Errorf("%s IsOpen = %t, want %t", test.status, got, test.open)
+		}
+		if got := test.status.IsReturned(); got != test.returned {
+			t.Errorf("%s IsReturned = %t, want %t", test.status, got, test.returned)
+		}
+		if got := test.status.ChargesReplacement(); got != test.chargesReplacement {
+			t.Errorf("%s ChargesReplacement = %t, want %t", test.status, got, test.chargesReplacement)
+		}
+	}
+}
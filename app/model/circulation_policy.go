@@ -0,0 +1,15 @@
+package model
+
+const PolicyWildcard = "*"
+
+type CirculationPolicy struct {
+	Id             int
+	PatronType     string
+	BookCategory   string
+	LoanDays       int
+	MaxLoanDays    int
+	MaxLoans       int
+	MaxRenewals    int
+	MaxFineCents   int64
+	BlockOnOverdue bool
+}
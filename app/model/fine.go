@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+const (
+	FineReasonLost    = "lost"
+	FineReasonDamaged = "damaged"
+	// FineReasonLostFound credits back a paid lost item charge when the copy
+	// turns up.
+	FineReasonLostFound = "lost_found"
+)
+
+type Fine struct {
+	Id          int
+	UserId      int
+	BorrowingId *int
+	AmountCents int64
+	Reason      string
+	PaidAt      *time.Time
+	WaivedAt    *time.Time
+	CreatedAt   time.Time
+}
+
+// LostItem is a row of the lost and damaged inventory report.
+type LostItem struct {
+	BorrowingId      int
+	Status           BorrowingStatus
+	BookId           int
+	BookTitle        string
+	BookIsbn         string
+	UserId           int
+	UserName         string
+	DueDate          time.Time
+	ReturnDate       *time.Time
+	ChargedCents     int64
+	OutstandingCents int64
+}
+
+type LostItemFilter struct {
+	Status BorrowingStatus
+	Limit  int
+	Offset int
+}
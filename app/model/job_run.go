@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+	JobRunStatusSkipped   = "skipped"
+)
+
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+type JobRun struct {
+	Id          int
+	JobName     string
+	ScheduledAt time.Time
+	TriggeredBy string
+	ActorId     *int
+	Status      string
+	Instance    string
+	Error       string
+	StartedAt   time.Time
+	FinishedAt  *time.Time
+}
+
+type JobRunFilter struct {
+	JobName string
+	Status  string
+	Limit   int
+	Offset  int
+}
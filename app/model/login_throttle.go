@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// LoginThrottle counts consecutive failed logins for one key, either an
+// account email or a client IP address.
+type LoginThrottle struct {
+	ThrottleKey string
+	Failures    int
+	LockedUntil *time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+const (
+	NotificationLoanCreated = "loan_created"
+	NotificationDueSoon     = "due_soon"
+	NotificationOverdue     = "overdue"
+	NotificationReturned    = "returned"
+)
+
+// Account notifications are sent regardless of preferences and are not
+// recorded in notifications, which tracks loan reminders.
+const (
+	NotificationVerifyEmail    = "verify_email"
+	NotificationPasswordReset  = "password_reset"
+	NotificationChangeEmail    = "change_email"
+	NotificationEmailChanged   = "email_changed"
+	NotificationAccountCreated = "account_created"
+)
+
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+)
+
+type Notification struct {
+	Id          int
+	UserId      int
+	BorrowingId int
+	Event       string
+	DueDate     time.Time
+	Recipient   string
+	Subject     string
+	Status      string
+	Error       string
+	CreatedAt   time.Time
+	SentAt      *time.Time
+}
+
+type NotificationPreference struct {
+	UserId       int
+	EmailEnabled bool
+	LoanCreated  bool
+	DueSoon      bool
+	Overdue      bool
+	Returned     bool
+	DueSoonDays  int
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// OidcLogin is an authorization request sent to the identity provider and
+// not yet answered. The state is stored hashed like other tokens; the nonce
+// and PKCE code verifier are needed in the clear to finish the login.
+type OidcLogin struct {
+	Id           int
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+const (
+	EventBookCreated            = "book.created"
+	EventBookUpdated            = "book.updated"
+	EventBookDeleted            = "book.deleted"
+	EventBookRestored           = "book.restored"
+	EventBorrowingCreated       = "borrowing.created"
+	EventBorrowingReturned      = "borrowing.returned"
+	EventBorrowingRenewed       = "borrowing.renewed"
+	EventBorrowingOverdue       = "borrowing.overdue"
+	EventBorrowingStatusChanged = "borrowing.status_changed"
+	EventUserRegistered         = "user.registered"
+)
+
+var EventTypes = []string{
+	EventBookCreated,
+	EventBookUpdated,
+	EventBookDeleted,
+	EventBookRestored,
+	EventBorrowingCreated,
+	EventBorrowingReturned,
+	EventBorrowingRenewed,
+	EventBorrowingOverdue,
+	EventBorrowingStatusChanged,
+	EventUserRegistered,
+}
+
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusDispatched = "dispatched"
+	OutboxStatusFailed     = "failed"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes and dispatched to subscribers afterwards.
+type OutboxEvent struct {
+	Id            int
+	EventId       string
+	Type          string
+	Entity        string
+	EntityId      int
+	Payload       string
+	ActorId       *int
+	RequestId     string
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	DispatchedAt  *time.Time
+}
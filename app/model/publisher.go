@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type Publisher struct {
+	Id        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type BookPublisher struct {
+	BookId int
+	Publisher
+}
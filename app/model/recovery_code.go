@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type RecoveryCode struct {
+	Id        int
+	UserId    int
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type Subject struct {
+	Id        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type BookSubject struct {
+	BookId int
+	Subject
+}
@@ -2,11 +2,65 @@ package model
 
 import "time"
 
+const (
+	RoleMember    = "member"
+	RoleLibrarian = "librarian"
+	RoleAdmin     = "admin"
+)
+
+const PatronTypeStandard = "standard"
+
+const (
+	// UserStatusPending accounts have registered but not yet verified their
+	// email address, and cannot log in.
+	UserStatusPending = "pending"
+	UserStatusActive  = "active"
+	// UserStatusSuspended accounts are blocked by staff and cannot log in.
+	UserStatusSuspended = "suspended"
+	// UserStatusExpired accounts have a lapsed membership. They keep their
+	// sessions and can still log in, see their loans and manage their own
+	// account, but the circulation policy refuses them new loans and
+	// renewals.
+	UserStatusExpired = "expired"
+)
+
 type User struct {
-	Id        int
-	Name      string
-	Email     string
-	Password  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	Id         int
+	Name       string
+	Email      string
+	Password   string
+	Role       string
+	PatronType string
+	// CardNumber is the library card issued to the patron, if any.
+	CardNumber *string
+	Phone      string
+	Address    string
+	Status     string
+	// StatusReason is the note staff left when they last changed Status.
+	StatusReason    string
+	EmailVerifiedAt *time.Time
+	// PendingEmail is the address the user asked to change to, until they
+	// confirm it from that address.
+	PendingEmail string
+	// SessionVersion is embedded in issued tokens; bumping it signs the
+	// user out everywhere.
+	SessionVersion int
+	// TotpSecret is set on enrollment and TotpEnabledAt once the user has
+	// confirmed it with a code. TotpLastStep is the last time step a code
+	// was accepted for, so a code cannot be used twice.
+	TotpSecret    string
+	TotpEnabledAt *time.Time
+	TotpLastStep  int64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time
+}
+
+type UserFilter struct {
+	Query      string
+	Status     string
+	Role       string
+	PatronType string
+	Limit      int
+	Offset     int
 }
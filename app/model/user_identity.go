@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// UserIdentity links a user to their account at an external identity
+// provider, identified by the provider's issuer and subject.
+type UserIdentity struct {
+	Id          int
+	UserId      int
+	Issuer      string
+	Subject     string
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt *time.Time
+}
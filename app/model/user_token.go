@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const (
+	UserTokenEmailVerification = "email_verification"
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailChange       = "email_change"
+	// UserTokenLoginChallenge is handed out after a correct password when
+	// the account has two-factor authentication enabled.
+	UserTokenLoginChallenge = "login_challenge"
+)
+
+type UserToken struct {
+	Id        int
+	UserId    int
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
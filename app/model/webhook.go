@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// WebhookEventAll subscribes to every event type in EventTypes.
+const WebhookEventAll = "*"
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookSubscription struct {
+	Id        int
+	Url       string
+	Secret    string
+	Events    string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	Id             int
+	SubscriptionId int
+	EventId        string
+	Event          string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	ResponseBody   string
+	Error          string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionId int
+	Status         string
+	Limit          int
+	Offset         int
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"kukuh/go-gin-library-project/helper/mail"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+type Data struct {
+	Name       string
+	BookTitle  string
+	BorrowDate time.Time
+	DueDate    time.Time
+	ReturnDate *time.Time
+	DaysLeft   int
+	Link       string
+	ExpiresAt  time.Time
+}
+
+// Render builds the message for event from templates/<event>.txt.tmpl and
+// templates/<event>.html.tmpl. The subject is the text template's
+// "subject" block.
+func Render(event string, to string, data Data) (*mail.Message, error) {
+	// Every text template defines "subject", so each event is parsed on its
+	// own rather than into one shared set.
+	text, err := texttemplate.ParseFS(templateFS, "templates/"+event+".txt.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.ParseFS(templateFS, "templates/"+event+".html.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	err = text.ExecuteTemplate(&subject, "subject", data)
+	if err != nil {
+		return nil, err
+	}
+	err = text.Execute(&textBody, data)
+	if err != nil {
+		return nil, err
+	}
+	err = html.Execute(&htmlBody, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mail.Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    textBody.String(),
+		HTML:    htmlBody.String(),
+	}, nil
+}
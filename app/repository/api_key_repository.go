@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository interface {
+	Save(db *gorm.DB, apiKey *model.ApiKey) error
+	FindById(db *gorm.DB, apiKey *model.ApiKey, apiKeyId int) error
+	FindByPrefix(db *gorm.DB, apiKey *model.ApiKey, prefix string) error
+	FindAll(db *gorm.DB, apiKeys *[]model.ApiKey) error
+	Revoke(db *gorm.DB, apiKeyId int, now time.Time) error
+	Touch(db *gorm.DB, apiKeyId int, now time.Time, staleBefore time.Time) error
+}
+
+type ApiKeyRepositoryImpl struct {
+}
+
+func NewApiKeyRepository() ApiKeyRepository {
+	return &ApiKeyRepositoryImpl{}
+}
+
+func (r ApiKeyRepositoryImpl) Save(db *gorm.DB, apiKey *model.ApiKey) error {
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at, created_at) VALUES (?,?,?,?,?,?,?)`
+	result := db.Exec(query, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, apiKey.Scopes, apiKey.CreatedBy, apiKey.ExpiresAt, apiKey.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&apiKey.Id).Error
+}
+
+func (r ApiKeyRepositoryImpl) FindById(db *gorm.DB, apiKey *model.ApiKey, apiKeyId int) error {
+	result := db.Raw("SELECT * from api_keys WHERE id = ?", apiKeyId).Scan(&apiKey)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r ApiKeyRepositoryImpl) FindByPrefix(db *gorm.DB, apiKey *model.ApiKey, prefix string) error {
+	result := db.Raw("SELECT * from api_keys WHERE prefix = ?", prefix).Scan(&apiKey)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r ApiKeyRepositoryImpl) FindAll(db *gorm.DB, apiKeys *[]model.ApiKey) error {
+	return db.Raw("SELECT * from api_keys ORDER BY id").Scan(apiKeys).Error
+}
+
+func (r ApiKeyRepositoryImpl) Revoke(db *gorm.DB, apiKeyId int, now time.Time) error {
+	result := db.Exec("UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", now, apiKeyId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// Touch records that the key was used. It only writes when the last
+// recorded use is older than staleBefore, so busy keys do not cost a write
+// per request.
+func (r ApiKeyRepositoryImpl) Touch(db *gorm.DB, apiKeyId int, now time.Time, staleBefore time.Time) error {
+	return db.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)", now, apiKeyId, staleBefore).Error
+}
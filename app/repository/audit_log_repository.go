@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository interface {
+	Save(db *gorm.DB, auditLog *model.AuditLog) error
+	FindAll(db *gorm.DB, auditLogs *[]model.AuditLog, total *int64, filter *model.AuditLogFilter) error
+}
+
+type AuditLogRepositoryImpl struct {
+}
+
+func NewAuditLogRepository() AuditLogRepository {
+	return &AuditLogRepositoryImpl{}
+}
+
+func (r AuditLogRepositoryImpl) Save(db *gorm.DB, auditLog *model.AuditLog) error {
+	query := `INSERT INTO audit_logs (actor_id, action, entity, entity_id, changes, request_id, created_at) 
+	VALUES (?,?,?,?,?,?,?)`
+	result := db.Exec(query, auditLog.ActorId, auditLog.Action, auditLog.Entity, auditLog.EntityId, auditLog.Changes, auditLog.RequestId, auditLog.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return nil
+}
+
+func (r AuditLogRepositoryImpl) FindAll(db *gorm.DB, auditLogs *[]model.AuditLog, total *int64, filter *model.AuditLogFilter) error {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	if filter.ActorId != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorId)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Entity != "" {
+		conditions = append(conditions, "entity = ?")
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityId != 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityId)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	err := db.Raw("SELECT COUNT(*) from audit_logs"+where, args...).Scan(total).Error
+	if err != nil {
+		return err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	return db.Raw("SELECT * from audit_logs"+where+" ORDER BY id DESC LIMIT ? OFFSET ?", args...).Scan(&auditLogs).Error
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+
+	"gorm.io/gorm"
+)
+
+type AuthorRepository interface {
+	Save(db *gorm.DB, author *model.Author) error
+	Find(db *gorm.DB, author *model.Author, authorId int) error
+	Update(db *gorm.DB, author *model.Author) error
+	Delete(db *gorm.DB, authorId int) error
+	CountBooks(db *gorm.DB, count *int64, authorId int) error
+	FindAll(db *gorm.DB, authors *[]model.Author) error
+	FindByIds(db *gorm.DB, authors *[]model.Author, authorIds []int) error
+	FindByBookIds(db *gorm.DB, bookAuthors *[]model.BookAuthor, bookIds []int) error
+}
+
+type AuthorRepositoryImpl struct {
+}
+
+func NewAuthorRepository() AuthorRepository {
+	return &AuthorRepositoryImpl{}
+}
+
+func (r AuthorRepositoryImpl) Save(db *gorm.DB, author *model.Author) error {
+	query := `INSERT INTO authors (name, bio, created_at, updated_at) VALUES (?,?,?,?)`
+	result := db.Exec(query, author.Name, author.Bio, author.CreatedAt, author.UpdatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&author.Id).Error
+}
+
+func (r AuthorRepositoryImpl) Find(db *gorm.DB, author *model.Author, authorId int) error {
+	result := db.Raw("SELECT * FROM authors WHERE id = ?", authorId).Scan(&author)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r AuthorRepositoryImpl) Update(db *gorm.DB, author *model.Author) error {
+	result := db.Exec("UPDATE authors set name = ?, bio = ?, updated_at = ? WHERE id = ?", author.Name, author.Bio, author.UpdatedAt, author.Id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r AuthorRepositoryImpl) Delete(db *gorm.DB, authorId int) error {
+	result := db.Exec("DELETE FROM authors where id = ?", authorId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// CountBooks counts the books linked to the author, deleted ones included
+// since their links are kept until they are purged.
+func (r AuthorRepositoryImpl) CountBooks(db *gorm.DB, count *int64, authorId int) error {
+	return db.Raw("SELECT COUNT(*) from book_authors WHERE author_id = ?", authorId).Scan(count).Error
+}
+
+func (r AuthorRepositoryImpl) FindAll(db *gorm.DB, authors *[]model.Author) error {
+	return db.Raw("SELECT * from authors ORDER BY name").Scan(&authors).Error
+}
+
+func (r AuthorRepositoryImpl) FindByIds(db *gorm.DB, authors *[]model.Author, authorIds []int) error {
+	if len(authorIds) == 0 {
+		return nil
+	}
+	return db.Raw("SELECT * from authors WHERE id IN ?", authorIds).Scan(&authors).Error
+}
+
+func (r AuthorRepositoryImpl) FindByBookIds(db *gorm.DB, bookAuthors *[]model.BookAuthor, bookIds []int) error {
+	if len(bookIds) == 0 {
+		return nil
+	}
+	query := `SELECT ba.book_id, a.* FROM authors a
+	JOIN book_authors ba ON ba.author_id = a.id
+	WHERE ba.book_id IN ? ORDER BY ba.position`
+	return db.Raw(query, bookIds).Scan(&bookAuthors).Error
+}
@@ -3,17 +3,33 @@ package repository
 import (
 	"errors"
 	"kukuh/go-gin-library-project/app/model"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+var ErrVersionConflict = errors.New("book was modified by another request")
+
+var ErrOutOfStock = errors.New("book is out of stock")
+
 type BookRepository interface {
 	Save(db *gorm.DB, book *model.Book) error
 	Find(db *gorm.DB, book *model.Book, bookId int) error
 	Update(db *gorm.DB, book *model.Book) error
 	Delete(db *gorm.DB, bookId int) error
-	FindAll(db *gorm.DB, books *[]model.Book) error
-	UpdateQuantity(db *gorm.DB, book *model.Book) error
+	FindAll(db *gorm.DB, books *[]model.Book, filter *model.BookFilter) error
+	TakeCopy(db *gorm.DB, book *model.Book) error
+	PutBackCopy(db *gorm.DB, book *model.Book) error
+	UpdateCover(db *gorm.DB, book *model.Book) error
+	UpdateAuthors(db *gorm.DB, bookId int, authorIds []int) error
+	UpdatePublishers(db *gorm.DB, bookId int, publisherIds []int) error
+	UpdateSubjects(db *gorm.DB, bookId int, subjectIds []int) error
+	FindDeleted(db *gorm.DB, books *[]model.Book) error
+	FindWithDeleted(db *gorm.DB, book *model.Book, bookId int) error
+	FindByIsbnWithDeleted(db *gorm.DB, books *[]model.Book, isbn string) error
+	Restore(db *gorm.DB, bookId int) error
+	Purge(db *gorm.DB, deletedBefore time.Time) (int64, error)
 }
 
 type BookRepositoryImpl struct {
@@ -24,52 +40,196 @@ func NewBookRepository() BookRepository {
 }
 
 func (r BookRepositoryImpl) Save(db *gorm.DB, book *model.Book) error {
-	query := `INSERT INTO books (title, author, isbn, publication_year, quantity, created_at, updated_at) 
-	VALUES (?,?,?,?,?,?,?)`
-	result := db.Exec(query, book.Title, book.Author, book.Isbn, book.PublicationYear, book.Quantity, book.CreatedAt, book.UpdatedAt)
+	query := `INSERT INTO books (title, isbn, category, publication_year, quantity, replacement_cost_cents, version, created_at, updated_at) 
+	VALUES (?,?,?,?,?,?,?,?,?)`
+	result := db.Exec(query, book.Title, book.Isbn, book.Category, book.PublicationYear, book.Quantity, book.ReplacementCostCents, book.Version, book.CreatedAt, book.UpdatedAt)
 
 	if result.RowsAffected == 0 {
 		return errors.New("failed to insert")
 	}
-	return nil
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&book.Id).Error
 }
 
 func (r BookRepositoryImpl) Find(db *gorm.DB, book *model.Book, bookId int) error {
-	err := db.Raw("SELECT * FROM books WHERE id = ?", bookId).Scan(&book).Error
-	if err != nil {
-		return err
+	result := db.Raw("SELECT * FROM books WHERE id = ? AND deleted_at IS NULL", bookId).Scan(&book)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
 	}
 	return nil
 }
 
-func (r BookRepositoryImpl) Update(db *gorm.DB, book *model.Book) error {
-	result := db.Exec("UPDATE books set title = ?, author = ?, isbn = ?, publication_year = ?, quantity = ?, updated_at = ? WHERE id = ?", book.Title, book.Author, book.Isbn, book.PublicationYear, book.Quantity, book.UpdatedAt, book.Id)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+// FindWithDeleted is Find including deleted books, for settling loans of
+// copies whose book has since been retired.
+func (r BookRepositoryImpl) FindWithDeleted(db *gorm.DB, book *model.Book, bookId int) error {
+	result := db.Raw("SELECT * FROM books WHERE id = ?", bookId).Scan(&book)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
 	return nil
 }
 
+// FindByIsbnWithDeleted looks up the book holding isbn, deleted ones
+// included since they keep their ISBN until purged.
+func (r BookRepositoryImpl) FindByIsbnWithDeleted(db *gorm.DB, books *[]model.Book, isbn string) error {
+	return db.Raw("SELECT * FROM books WHERE isbn = ?", isbn).Scan(books).Error
+}
+
+// Update only succeeds while the stored version still equals book.Version,
+// and bumps the version on success.
+func (r BookRepositoryImpl) Update(db *gorm.DB, book *model.Book) error {
+	result := db.Exec("UPDATE books set title = ?, isbn = ?, category = ?, publication_year = ?, quantity = ?, replacement_cost_cents = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL", book.Title, book.Isbn, book.Category, book.PublicationYear, book.Quantity, book.ReplacementCostCents, book.UpdatedAt, book.Id, book.Version)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	book.Version++
+	return nil
+}
+
 func (r BookRepositoryImpl) Delete(db *gorm.DB, bookId int) error {
-	result := db.Exec("DELETE FROM books where id = ?", bookId)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+	result := db.Exec("UPDATE books SET deleted_at = ? where id = ? AND deleted_at IS NULL", time.Now(), bookId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
 	return nil
 }
 
-func (r BookRepositoryImpl) FindAll(db *gorm.DB, books *[]model.Book) error {
-	result := db.Raw("SELECT * from books").Scan(&books)
+func (r BookRepositoryImpl) FindAll(db *gorm.DB, books *[]model.Book, filter *model.BookFilter) error {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.AuthorId != 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = ?)")
+		args = append(args, filter.AuthorId)
+	}
+	if filter.PublisherId != 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM book_publishers bp WHERE bp.book_id = books.id AND bp.publisher_id = ?)")
+		args = append(args, filter.PublisherId)
+	}
+	if filter.SubjectId != 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM book_subjects bs WHERE bs.book_id = books.id AND bs.subject_id = ?)")
+		args = append(args, filter.SubjectId)
+	}
+
+	query := "SELECT * from books WHERE " + strings.Join(conditions, " AND ")
+
+	return db.Raw(query, args...).Scan(&books).Error
+}
+
+// TakeCopy lends out one copy of a book that is not deleted. The stock is
+// decremented in place so concurrent checkouts cannot take the same last
+// copy; it fails with ErrOutOfStock when none is left. book is refreshed
+// with the new quantity and version.
+func (r BookRepositoryImpl) TakeCopy(db *gorm.DB, book *model.Book) error {
+	result := db.Exec("UPDATE books set quantity = quantity - 1, version = version + 1 WHERE id = ? AND quantity > 0 AND deleted_at IS NULL", book.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOutOfStock
+	}
+	return r.reloadQuantity(db, book)
+}
+
+// PutBackCopy returns one copy to stock, incrementing in place like
+// TakeCopy.
+func (r BookRepositoryImpl) PutBackCopy(db *gorm.DB, book *model.Book) error {
+	result := db.Exec("UPDATE books set quantity = quantity + 1, version = version + 1 WHERE id = ?", book.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return r.reloadQuantity(db, book)
+}
+
+func (r BookRepositoryImpl) reloadQuantity(db *gorm.DB, book *model.Book) error {
+	return db.Raw("SELECT quantity, version from books WHERE id = ?", book.Id).Row().Scan(&book.Quantity, &book.Version)
+}
+
+func (r BookRepositoryImpl) UpdateCover(db *gorm.DB, book *model.Book) error {
+	result := db.Exec("UPDATE books set cover_key = ?, updated_at = ?, version = version + 1 WHERE id = ?", book.CoverKey, book.UpdatedAt, book.Id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
+	book.Version++
 	return nil
 }
 
-func (r BookRepositoryImpl) UpdateQuantity(db *gorm.DB, book *model.Book) error {
-	result := db.Exec("UPDATE books set quantity = ? WHERE id = ?", book.Quantity, book.Id)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+func (r BookRepositoryImpl) UpdateAuthors(db *gorm.DB, bookId int, authorIds []int) error {
+	err := db.Exec("DELETE FROM book_authors WHERE book_id = ?", bookId).Error
+	if err != nil {
+		return err
+	}
+	for position, authorId := range authorIds {
+		err = db.Exec("INSERT INTO book_authors (book_id, author_id, position) VALUES (?,?,?)", bookId, authorId, position).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r BookRepositoryImpl) UpdatePublishers(db *gorm.DB, bookId int, publisherIds []int) error {
+	err := db.Exec("DELETE FROM book_publishers WHERE book_id = ?", bookId).Error
+	if err != nil {
+		return err
+	}
+	for _, publisherId := range publisherIds {
+		err = db.Exec("INSERT INTO book_publishers (book_id, publisher_id) VALUES (?,?)", bookId, publisherId).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r BookRepositoryImpl) UpdateSubjects(db *gorm.DB, bookId int, subjectIds []int) error {
+	err := db.Exec("DELETE FROM book_subjects WHERE book_id = ?", bookId).Error
+	if err != nil {
+		return err
+	}
+	for _, subjectId := range subjectIds {
+		err = db.Exec("INSERT INTO book_subjects (book_id, subject_id) VALUES (?,?)", bookId, subjectId).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r BookRepositoryImpl) FindDeleted(db *gorm.DB, books *[]model.Book) error {
+	return db.Raw("SELECT * from books WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC").Scan(&books).Error
+}
+
+func (r BookRepositoryImpl) Restore(db *gorm.DB, bookId int) error {
+	result := db.Exec("UPDATE books SET deleted_at = NULL, updated_at = ? WHERE id = ? AND deleted_at IS NOT NULL", time.Now(), bookId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
 	return nil
 }
+
+// Purge permanently removes books deleted before the given time. Books still
+// referenced by borrowings are kept so circulation history stays intact.
+func (r BookRepositoryImpl) Purge(db *gorm.DB, deletedBefore time.Time) (int64, error) {
+	query := `DELETE FROM books WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	AND NOT EXISTS (SELECT 1 FROM borrowings WHERE borrowings.book_id = books.id)`
+	result := db.Exec(query, deletedBefore)
+	return result.RowsAffected, result.Error
+}
@@ -3,15 +3,33 @@ package repository
 import (
 	"errors"
 	"kukuh/go-gin-library-project/app/model"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrBorrowingStatusChanged is returned by UpdateStatus when the borrowing is
+// no longer in the status it was read in, typically because a concurrent
+// request already returned it.
+var ErrBorrowingStatusChanged = errors.New("borrowing status changed")
+
 type BorrowingRepository interface {
 	Save(db *gorm.DB, borrowing *model.Borrowing) error
 	Find(db *gorm.DB, borrowing *model.Borrowing, borrowingId int) error
-	FindAll(db *gorm.DB, borrowings *[]model.Borrowing) error
-	UpdateStatus(db *gorm.DB, borrowing *model.Borrowing) error
+	FindJoin(db *gorm.DB, borrowing *model.BorrowingJoin, borrowingId int) error
+	FindAll(db *gorm.DB, borrowings *[]model.BorrowingJoin) error
+	FindByUserId(db *gorm.DB, borrowings *[]model.BorrowingJoin, total *int64, filter *model.BorrowingFilter) error
+	UpdateStatus(db *gorm.DB, borrowing *model.Borrowing, from model.BorrowingStatus) error
+	UpdateDueDate(db *gorm.DB, borrowing *model.Borrowing) error
+	CountActiveByUserId(db *gorm.DB, count *int64, userId int) error
+	CountActiveByBookId(db *gorm.DB, count *int64, bookId int) error
+	CountOverdueByUserId(db *gorm.DB, count *int64, userId int, now time.Time, exceptBorrowingId int) error
+	FindOverdue(db *gorm.DB, borrowings *[]model.Borrowing, now time.Time) error
+	MarkOverdue(db *gorm.DB, now time.Time) (int64, error)
+	FindByStatus(db *gorm.DB, borrowings *[]model.Borrowing, status model.BorrowingStatus) error
+	FindDueSoon(db *gorm.DB, borrowings *[]model.Borrowing, now time.Time, defaultDays int) error
+	FindLost(db *gorm.DB, items *[]model.LostItem, total *int64, filter *model.LostItemFilter) error
 }
 
 type BorrowingRepositoryImpl struct {
@@ -29,7 +47,7 @@ func (r BorrowingRepositoryImpl) Save(db *gorm.DB, borrowing *model.Borrowing) e
 	if result.RowsAffected == 0 {
 		return errors.New("failed to insert")
 	}
-	return nil
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&borrowing.Id).Error
 }
 
 func (r BorrowingRepositoryImpl) Find(db *gorm.DB, borrowing *model.Borrowing, borrowingId int) error {
@@ -40,18 +58,141 @@ func (r BorrowingRepositoryImpl) Find(db *gorm.DB, borrowing *model.Borrowing, b
 	return nil
 }
 
-func (r BorrowingRepositoryImpl) FindAll(db *gorm.DB, borrowings *[]model.Borrowing) error {
-	result := db.Raw("SELECT * from borrowings").Scan(&borrowings)
+// borrowingJoinQuery reads borrowings with the book and borrower columns
+// needed for expanded responses in the same query.
+const borrowingJoinQuery = `SELECT b.*, bk.title AS book_title, bk.isbn AS book_isbn, u.name AS user_name
+	FROM borrowings b
+	JOIN books bk ON bk.id = b.book_id
+	JOIN users u ON u.id = b.user_id`
+
+func (r BorrowingRepositoryImpl) FindJoin(db *gorm.DB, borrowing *model.BorrowingJoin, borrowingId int) error {
+	return db.Raw(borrowingJoinQuery+" WHERE b.id = ?", borrowingId).Scan(&borrowing).Error
+}
+
+func (r BorrowingRepositoryImpl) FindAll(db *gorm.DB, borrowings *[]model.BorrowingJoin) error {
+	result := db.Raw(borrowingJoinQuery + " ORDER BY b.id").Scan(&borrowings)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
 	return nil
 }
 
-func (r BorrowingRepositoryImpl) UpdateStatus(db *gorm.DB, borrowing *model.Borrowing) error {
-	result := db.Exec("UPDATE borrowings set status = ?, return_date = ? WHERE id = ?", borrowing.Status, borrowing.ReturnDate, borrowing.Id)
+func (r BorrowingRepositoryImpl) FindByUserId(db *gorm.DB, borrowings *[]model.BorrowingJoin, total *int64, filter *model.BorrowingFilter) error {
+	conditions := []string{"b.user_id = ?"}
+	args := []interface{}{filter.UserId}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "b.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Active != nil {
+		if *filter.Active {
+			conditions = append(conditions, "b.return_date IS NULL")
+		} else {
+			conditions = append(conditions, "b.return_date IS NOT NULL")
+		}
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	err := db.Raw("SELECT COUNT(*) from borrowings b"+where, args...).Scan(total).Error
+	if err != nil {
+		return err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	return db.Raw(borrowingJoinQuery+where+" ORDER BY b.borrow_date DESC, b.id DESC LIMIT ? OFFSET ?", args...).Scan(&borrowings).Error
+}
+
+func (r BorrowingRepositoryImpl) UpdateStatus(db *gorm.DB, borrowing *model.Borrowing, from model.BorrowingStatus) error {
+	result := db.Exec("UPDATE borrowings set status = ?, return_date = ? WHERE id = ? AND status = ?", borrowing.Status, borrowing.ReturnDate, borrowing.Id, from)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBorrowingStatusChanged
+	}
+	return nil
+}
+
+func (r BorrowingRepositoryImpl) UpdateDueDate(db *gorm.DB, borrowing *model.Borrowing) error {
+	result := db.Exec("UPDATE borrowings set due_date = ?, renewal_count = ?, status = ? WHERE id = ?", borrowing.DueDate, borrowing.RenewalCount, borrowing.Status, borrowing.Id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
 	return nil
 }
+
+// CountActiveByUserId counts the patron's open loans. Lost loans and claimed
+// returns are settled through fines and staff, not the loan limit.
+func (r BorrowingRepositoryImpl) CountActiveByUserId(db *gorm.DB, count *int64, userId int) error {
+	return db.Raw("SELECT COUNT(*) from borrowings WHERE user_id = ? AND status IN ('borrowed','overdue')", userId).Scan(count).Error
+}
+
+// CountActiveByBookId counts loans whose copy may still come back: open
+// ones and those claimed returned. Lost copies are not expected back, so
+// they do not keep the book from being retired.
+func (r BorrowingRepositoryImpl) CountActiveByBookId(db *gorm.DB, count *int64, bookId int) error {
+	return db.Raw("SELECT COUNT(*) from borrowings WHERE book_id = ? AND status IN ('borrowed','overdue','claimed_returned')", bookId).Scan(count).Error
+}
+
+// CountOverdueByUserId counts the patron's open loans past due other than
+// exceptBorrowingId, so a loan being renewed does not block itself.
+func (r BorrowingRepositoryImpl) CountOverdueByUserId(db *gorm.DB, count *int64, userId int, now time.Time, exceptBorrowingId int) error {
+	return db.Raw("SELECT COUNT(*) from borrowings WHERE user_id = ? AND status IN ('borrowed','overdue') AND due_date < ? AND id <> ?",
+		userId, now, exceptBorrowingId).Scan(count).Error
+}
+
+// FindOverdue locks and returns the open loans that are past due but not yet
+// marked overdue.
+func (r BorrowingRepositoryImpl) FindOverdue(db *gorm.DB, borrowings *[]model.Borrowing, now time.Time) error {
+	return db.Raw("SELECT * from borrowings WHERE status = 'borrowed' AND return_date IS NULL AND due_date < ? FOR UPDATE", now).Scan(&borrowings).Error
+}
+
+func (r BorrowingRepositoryImpl) MarkOverdue(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Exec("UPDATE borrowings set status = 'overdue' WHERE status = 'borrowed' AND return_date IS NULL AND due_date < ?", now)
+	return result.RowsAffected, result.Error
+}
+
+func (r BorrowingRepositoryImpl) FindByStatus(db *gorm.DB, borrowings *[]model.Borrowing, status model.BorrowingStatus) error {
+	return db.Raw("SELECT * from borrowings WHERE status = ?", status).Scan(&borrowings).Error
+}
+
+// FindDueSoon returns open loans due within each patron's reminder window,
+// falling back to defaultDays for patrons without notification preferences.
+func (r BorrowingRepositoryImpl) FindDueSoon(db *gorm.DB, borrowings *[]model.Borrowing, now time.Time, defaultDays int) error {
+	query := `SELECT b.* from borrowings b
+	LEFT JOIN notification_preferences p ON p.user_id = b.user_id
+	WHERE b.status = 'borrowed' AND b.return_date IS NULL AND b.due_date >= ?
+	AND b.due_date < DATE_ADD(?, INTERVAL COALESCE(p.due_soon_days, ?) DAY)`
+	return db.Raw(query, now, now, defaultDays).Scan(&borrowings).Error
+}
+
+// FindLost returns lost and damaged loans with what the patron has been
+// charged for them, net of waivers and credits, and how much is still unpaid.
+func (r BorrowingRepositoryImpl) FindLost(db *gorm.DB, items *[]model.LostItem, total *int64, filter *model.LostItemFilter) error {
+	conditions := []string{"b.status IN (?)"}
+	args := []interface{}{[]model.BorrowingStatus{model.BorrowingStatusLost, model.BorrowingStatusDamaged}}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "b.status = ?")
+		args = append(args, filter.Status)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	err := db.Raw("SELECT COUNT(*) from borrowings b"+where, args...).Scan(total).Error
+	if err != nil {
+		return err
+	}
+
+	charges := `FROM fines f WHERE f.borrowing_id = b.id AND f.waived_at IS NULL
+	AND f.reason IN ('` + model.FineReasonLost + `', '` + model.FineReasonDamaged + `', '` + model.FineReasonLostFound + `')`
+	query := `SELECT b.id AS borrowing_id, b.status, b.book_id, bk.title AS book_title, bk.isbn AS book_isbn,
+	b.user_id, u.name AS user_name, b.due_date, b.return_date,
+	(SELECT COALESCE(SUM(f.amount_cents), 0) ` + charges + `) AS charged_cents,
+	(SELECT COALESCE(SUM(f.amount_cents), 0) ` + charges + ` AND f.paid_at IS NULL) AS outstanding_cents
+	FROM borrowings b
+	JOIN books bk ON bk.id = b.book_id
+	JOIN users u ON u.id = b.user_id`
+	args = append(args, filter.Limit, filter.Offset)
+	return db.Raw(query+where+" ORDER BY b.id DESC LIMIT ? OFFSET ?", args...).Scan(&items).Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+
+	"gorm.io/gorm"
+)
+
+type CirculationPolicyRepository interface {
+	Save(db *gorm.DB, policy *model.CirculationPolicy) error
+	Delete(db *gorm.DB, policyId int) error
+	FindAll(db *gorm.DB, policies *[]model.CirculationPolicy) error
+	FindMatching(db *gorm.DB, policy *model.CirculationPolicy, patronType string, bookCategory string) error
+}
+
+type CirculationPolicyRepositoryImpl struct {
+}
+
+func NewCirculationPolicyRepository() CirculationPolicyRepository {
+	return &CirculationPolicyRepositoryImpl{}
+}
+
+// Save inserts the policy, or replaces the existing policy for the same
+// patron type and book category.
+func (r CirculationPolicyRepositoryImpl) Save(db *gorm.DB, policy *model.CirculationPolicy) error {
+	query := `INSERT INTO circulation_policies (patron_type, book_category, loan_days, max_loan_days, max_loans, max_renewals, max_fine_cents, block_on_overdue) 
+	VALUES (?,?,?,?,?,?,?,?)
+	ON DUPLICATE KEY UPDATE loan_days = VALUES(loan_days), max_loan_days = VALUES(max_loan_days), max_loans = VALUES(max_loans),
+	max_renewals = VALUES(max_renewals), max_fine_cents = VALUES(max_fine_cents), block_on_overdue = VALUES(block_on_overdue)`
+	result := db.Exec(query, policy.PatronType, policy.BookCategory, policy.LoanDays, policy.MaxLoanDays, policy.MaxLoans, policy.MaxRenewals, policy.MaxFineCents, policy.BlockOnOverdue)
+	if result.Error != nil {
+		return result.Error
+	}
+	return db.Raw("SELECT id from circulation_policies WHERE patron_type = ? AND book_category = ?", policy.PatronType, policy.BookCategory).Scan(&policy.Id).Error
+}
+
+func (r CirculationPolicyRepositoryImpl) Delete(db *gorm.DB, policyId int) error {
+	result := db.Exec("DELETE FROM circulation_policies where id = ?", policyId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r CirculationPolicyRepositoryImpl) FindAll(db *gorm.DB, policies *[]model.CirculationPolicy) error {
+	return db.Raw("SELECT * from circulation_policies ORDER BY patron_type, book_category").Scan(&policies).Error
+}
+
+// FindMatching picks the most specific policy for the patron type and book
+// category, falling back to wildcard rows.
+func (r CirculationPolicyRepositoryImpl) FindMatching(db *gorm.DB, policy *model.CirculationPolicy, patronType string, bookCategory string) error {
+	query := `SELECT * from circulation_policies
+	WHERE patron_type IN (?, ?) AND book_category IN (?, ?)
+	ORDER BY patron_type = ? DESC, book_category = ? DESC LIMIT 1`
+	result := db.Raw(query, patronType, model.PolicyWildcard, bookCategory, model.PolicyWildcard, patronType, bookCategory).Scan(&policy)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("no circulation policy configured")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FineRepository interface {
+	Save(db *gorm.DB, fine *model.Fine) error
+	FindByBorrowingId(db *gorm.DB, fines *[]model.Fine, borrowingId int, reason string) error
+	Waive(db *gorm.DB, fineId int, now time.Time) error
+	SumOutstandingByUserId(db *gorm.DB, amountCents *int64, userId int) error
+}
+
+type FineRepositoryImpl struct {
+}
+
+func NewFineRepository() FineRepository {
+	return &FineRepositoryImpl{}
+}
+
+func (r FineRepositoryImpl) Save(db *gorm.DB, fine *model.Fine) error {
+	query := `INSERT INTO fines (user_id, borrowing_id, amount_cents, reason, created_at) 
+	VALUES (?,?,?,?,?)`
+	result := db.Exec(query, fine.UserId, fine.BorrowingId, fine.AmountCents, fine.Reason, fine.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&fine.Id).Error
+}
+
+// FindByBorrowingId returns the borrowing's fines for reason that have not
+// been waived.
+func (r FineRepositoryImpl) FindByBorrowingId(db *gorm.DB, fines *[]model.Fine, borrowingId int, reason string) error {
+	return db.Raw("SELECT * from fines WHERE borrowing_id = ? AND reason = ? AND waived_at IS NULL", borrowingId, reason).Scan(&fines).Error
+}
+
+func (r FineRepositoryImpl) Waive(db *gorm.DB, fineId int, now time.Time) error {
+	result := db.Exec("UPDATE fines set waived_at = ? WHERE id = ? AND paid_at IS NULL AND waived_at IS NULL", now, fineId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r FineRepositoryImpl) SumOutstandingByUserId(db *gorm.DB, amountCents *int64, userId int) error {
+	return db.Raw("SELECT COALESCE(SUM(amount_cents), 0) from fines WHERE user_id = ? AND paid_at IS NULL AND waived_at IS NULL", userId).Scan(amountCents).Error
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ErrJobRunClaimed is returned by Save when another instance has already
+// recorded a run for the same job and scheduled time.
+var ErrJobRunClaimed = errors.New("job run already claimed")
+
+// mysqlDuplicateEntry is MySQL's ER_DUP_ENTRY, raised when an insert
+// violates a unique key.
+const mysqlDuplicateEntry = 1062
+
+type JobRunRepository interface {
+	Save(db *gorm.DB, jobRun *model.JobRun) error
+	Finish(db *gorm.DB, jobRun *model.JobRun) error
+	FindAll(db *gorm.DB, jobRuns *[]model.JobRun, total *int64, filter *model.JobRunFilter) error
+	TryLock(db *gorm.DB, jobName string, owner string, now time.Time, until time.Time) (bool, error)
+	Unlock(db *gorm.DB, jobName string, owner string, now time.Time) error
+}
+
+type JobRunRepositoryImpl struct {
+}
+
+func NewJobRunRepository() JobRunRepository {
+	return &JobRunRepositoryImpl{}
+}
+
+func (r JobRunRepositoryImpl) Save(db *gorm.DB, jobRun *model.JobRun) error {
+	query := `INSERT INTO job_runs (job_name, scheduled_at, triggered_by, actor_id, status, instance, error, started_at) 
+	VALUES (?,?,?,?,?,?,?,?)`
+	result := db.Exec(query, jobRun.JobName, jobRun.ScheduledAt, jobRun.TriggeredBy, jobRun.ActorId, jobRun.Status, jobRun.Instance, jobRun.Error, jobRun.StartedAt)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(result.Error, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return ErrJobRunClaimed
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&jobRun.Id).Error
+}
+
+func (r JobRunRepositoryImpl) Finish(db *gorm.DB, jobRun *model.JobRun) error {
+	result := db.Exec("UPDATE job_runs SET status = ?, error = ?, finished_at = ? WHERE id = ?", jobRun.Status, jobRun.Error, jobRun.FinishedAt, jobRun.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r JobRunRepositoryImpl) FindAll(db *gorm.DB, jobRuns *[]model.JobRun, total *int64, filter *model.JobRunFilter) error {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	if filter.JobName != "" {
+		conditions = append(conditions, "job_name = ?")
+		args = append(args, filter.JobName)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	err := db.Raw("SELECT COUNT(*) from job_runs"+where, args...).Scan(total).Error
+	if err != nil {
+		return err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	return db.Raw("SELECT * from job_runs"+where+" ORDER BY id DESC LIMIT ? OFFSET ?", args...).Scan(&jobRuns).Error
+}
+
+// TryLock takes the lease on jobName for owner until the given time. The
+// lease is only taken over once the previous holder's has expired, so it
+// reports false while another run of the job is in progress.
+func (r JobRunRepositoryImpl) TryLock(db *gorm.DB, jobName string, owner string, now time.Time, until time.Time) (bool, error) {
+	query := `INSERT INTO job_locks (job_name, locked_by, locked_until) VALUES (?,?,?)
+	ON DUPLICATE KEY UPDATE locked_by = IF(locked_until < ?, VALUES(locked_by), locked_by),
+	locked_until = IF(locked_by = VALUES(locked_by), VALUES(locked_until), locked_until)`
+	err := db.Exec(query, jobName, owner, until, now).Error
+	if err != nil {
+		return false, err
+	}
+
+	var lockedBy string
+	err = db.Raw("SELECT locked_by FROM job_locks WHERE job_name = ?", jobName).Scan(&lockedBy).Error
+	if err != nil {
+		return false, err
+	}
+	return lockedBy == owner, nil
+}
+
+func (r JobRunRepositoryImpl) Unlock(db *gorm.DB, jobName string, owner string, now time.Time) error {
+	return db.Exec("UPDATE job_locks SET locked_until = ? WHERE job_name = ? AND locked_by = ?", now, jobName, owner).Error
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginThrottleRepository interface {
+	Find(db *gorm.DB, throttle *model.LoginThrottle, key string) error
+	RegisterFailure(db *gorm.DB, throttle *model.LoginThrottle, key string, now time.Time, idleBefore time.Time) error
+	Lock(db *gorm.DB, key string, lockedUntil time.Time) error
+	Delete(db *gorm.DB, key string) error
+}
+
+type LoginThrottleRepositoryImpl struct {
+}
+
+func NewLoginThrottleRepository() LoginThrottleRepository {
+	return &LoginThrottleRepositoryImpl{}
+}
+
+func (r LoginThrottleRepositoryImpl) Find(db *gorm.DB, throttle *model.LoginThrottle, key string) error {
+	result := db.Raw("SELECT * from login_throttles WHERE throttle_key = ?", key).Scan(&throttle)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// RegisterFailure adds one failure to the key and loads the updated counter.
+// A counter that has not moved since idleBefore starts again from one.
+func (r LoginThrottleRepositoryImpl) RegisterFailure(db *gorm.DB, throttle *model.LoginThrottle, key string, now time.Time, idleBefore time.Time) error {
+	query := `INSERT INTO login_throttles (throttle_key, failures, updated_at) VALUES (?,1,?)
+		ON DUPLICATE KEY UPDATE failures = IF(updated_at < ?, 1, failures + 1), updated_at = VALUES(updated_at)`
+	result := db.Exec(query, key, now, idleBefore)
+	if result.Error != nil {
+		return result.Error
+	}
+	return r.Find(db, throttle, key)
+}
+
+func (r LoginThrottleRepositoryImpl) Lock(db *gorm.DB, key string, lockedUntil time.Time) error {
+	return db.Exec("UPDATE login_throttles SET locked_until = ? WHERE throttle_key = ?", lockedUntil, key).Error
+}
+
+func (r LoginThrottleRepositoryImpl) Delete(db *gorm.DB, key string) error {
+	return db.Exec("DELETE FROM login_throttles WHERE throttle_key = ?", key).Error
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Claim(db *gorm.DB, notification *model.Notification) (bool, error)
+	UpdateStatus(db *gorm.DB, notification *model.Notification) error
+	FindPreference(db *gorm.DB, preference *model.NotificationPreference, userId int) error
+	SavePreference(db *gorm.DB, preference *model.NotificationPreference) error
+}
+
+type NotificationRepositoryImpl struct {
+}
+
+func NewNotificationRepository() NotificationRepository {
+	return &NotificationRepositoryImpl{}
+}
+
+// Claim records a pending notification and reports whether the caller should
+// send it. A notification is sent at most once per borrowing, event and due
+// date; one that previously failed may be claimed again.
+func (r NotificationRepositoryImpl) Claim(db *gorm.DB, notification *model.Notification) (bool, error) {
+	query := `INSERT IGNORE INTO notifications (user_id, borrowing_id, event, due_date, recipient, subject, status, error, created_at) 
+	VALUES (?,?,?,?,?,?,?,?,?)`
+	result := db.Exec(query, notification.UserId, notification.BorrowingId, notification.Event, notification.DueDate, notification.Recipient,
+		notification.Subject, notification.Status, notification.Error, notification.CreatedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		result = db.Exec("UPDATE notifications SET status = ?, error = '' WHERE borrowing_id = ? AND event = ? AND due_date = ? AND status = ?",
+			notification.Status, notification.BorrowingId, notification.Event, notification.DueDate, model.NotificationStatusFailed)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return false, result.Error
+		}
+	}
+
+	err := db.Raw("SELECT id FROM notifications WHERE borrowing_id = ? AND event = ? AND due_date = ?",
+		notification.BorrowingId, notification.Event, notification.DueDate).Scan(&notification.Id).Error
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r NotificationRepositoryImpl) UpdateStatus(db *gorm.DB, notification *model.Notification) error {
+	result := db.Exec("UPDATE notifications SET recipient = ?, subject = ?, status = ?, error = ?, sent_at = ? WHERE id = ?",
+		notification.Recipient, notification.Subject, notification.Status, notification.Error, notification.SentAt, notification.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r NotificationRepositoryImpl) FindPreference(db *gorm.DB, preference *model.NotificationPreference, userId int) error {
+	result := db.Raw("SELECT * FROM notification_preferences WHERE user_id = ?", userId).Scan(&preference)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r NotificationRepositoryImpl) SavePreference(db *gorm.DB, preference *model.NotificationPreference) error {
+	query := `INSERT INTO notification_preferences (user_id, email_enabled, loan_created, due_soon, overdue, returned, due_soon_days, updated_at) 
+	VALUES (?,?,?,?,?,?,?,?)
+	ON DUPLICATE KEY UPDATE email_enabled = VALUES(email_enabled), loan_created = VALUES(loan_created), due_soon = VALUES(due_soon),
+	overdue = VALUES(overdue), returned = VALUES(returned), due_soon_days = VALUES(due_soon_days), updated_at = VALUES(updated_at)`
+	return db.Exec(query, preference.UserId, preference.EmailEnabled, preference.LoanCreated, preference.DueSoon, preference.Overdue,
+		preference.Returned, preference.DueSoonDays, preference.UpdatedAt).Error
+}
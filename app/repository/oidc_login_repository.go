@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OidcLoginRepository interface {
+	Save(db *gorm.DB, oidcLogin *model.OidcLogin) error
+	FindByStateHash(db *gorm.DB, oidcLogin *model.OidcLogin, stateHash string) error
+	Consume(db *gorm.DB, oidcLoginId int, now time.Time) error
+}
+
+type OidcLoginRepositoryImpl struct {
+}
+
+func NewOidcLoginRepository() OidcLoginRepository {
+	return &OidcLoginRepositoryImpl{}
+}
+
+func (r OidcLoginRepositoryImpl) Save(db *gorm.DB, oidcLogin *model.OidcLogin) error {
+	query := `INSERT INTO oidc_logins (state_hash, nonce, code_verifier, expires_at, created_at) VALUES (?,?,?,?,?)`
+	result := db.Exec(query, oidcLogin.StateHash, oidcLogin.Nonce, oidcLogin.CodeVerifier, oidcLogin.ExpiresAt, oidcLogin.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&oidcLogin.Id).Error
+}
+
+func (r OidcLoginRepositoryImpl) FindByStateHash(db *gorm.DB, oidcLogin *model.OidcLogin, stateHash string) error {
+	result := db.Raw("SELECT * from oidc_logins WHERE state_hash = ?", stateHash).Scan(&oidcLogin)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// Consume marks the login request answered. It fails if it already was, so
+// a callback cannot be replayed.
+func (r OidcLoginRepositoryImpl) Consume(db *gorm.DB, oidcLoginId int, now time.Time) error {
+	result := db.Exec("UPDATE oidc_logins SET used_at = ? WHERE id = ? AND used_at IS NULL", now, oidcLoginId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("login already used")
+	}
+	return nil
+}
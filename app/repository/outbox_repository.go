@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Save(db *gorm.DB, event *model.OutboxEvent) error
+	Update(db *gorm.DB, event *model.OutboxEvent) error
+	FindPending(db *gorm.DB, events *[]model.OutboxEvent, now time.Time, limit int) error
+	FindHandled(db *gorm.DB, subscribers *[]string, outboxId int) error
+	MarkHandled(db *gorm.DB, outboxId int, subscriber string, now time.Time) error
+	UnmarkHandled(db *gorm.DB, outboxId int, subscriber string) error
+}
+
+type OutboxRepositoryImpl struct {
+}
+
+func NewOutboxRepository() OutboxRepository {
+	return &OutboxRepositoryImpl{}
+}
+
+func (r OutboxRepositoryImpl) Save(db *gorm.DB, event *model.OutboxEvent) error {
+	query := `INSERT INTO outbox_events (event_id, type, entity, entity_id, payload, actor_id, request_id, status, attempts, next_attempt_at, created_at) 
+	VALUES (?,?,?,?,?,?,?,?,?,?,?)`
+	result := db.Exec(query, event.EventId, event.Type, event.Entity, event.EntityId, event.Payload, event.ActorId, event.RequestId,
+		event.Status, event.Attempts, event.NextAttemptAt, event.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return nil
+}
+
+func (r OutboxRepositoryImpl) Update(db *gorm.DB, event *model.OutboxEvent) error {
+	result := db.Exec("UPDATE outbox_events SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, dispatched_at = ? WHERE id = ?",
+		event.Status, event.Attempts, event.NextAttemptAt, event.LastError, event.DispatchedAt, event.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// FindPending locks a batch of events that are due for dispatch. Rows
+// already locked by another dispatcher are skipped rather than waited on.
+func (r OutboxRepositoryImpl) FindPending(db *gorm.DB, events *[]model.OutboxEvent, now time.Time, limit int) error {
+	return db.Raw("SELECT * FROM outbox_events WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED",
+		model.OutboxStatusPending, now, limit).Scan(&events).Error
+}
+
+func (r OutboxRepositoryImpl) FindHandled(db *gorm.DB, subscribers *[]string, outboxId int) error {
+	return db.Raw("SELECT subscriber FROM outbox_handled WHERE outbox_id = ?", outboxId).Scan(subscribers).Error
+}
+
+func (r OutboxRepositoryImpl) MarkHandled(db *gorm.DB, outboxId int, subscriber string, now time.Time) error {
+	return db.Exec("INSERT INTO outbox_handled (outbox_id, subscriber, handled_at) VALUES (?,?,?)", outboxId, subscriber, now).Error
+}
+
+func (r OutboxRepositoryImpl) UnmarkHandled(db *gorm.DB, outboxId int, subscriber string) error {
+	return db.Exec("DELETE FROM outbox_handled WHERE outbox_id = ? AND subscriber = ?", outboxId, subscriber).Error
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+
+	"gorm.io/gorm"
+)
+
+type PublisherRepository interface {
+	Save(db *gorm.DB, publisher *model.Publisher) error
+	Find(db *gorm.DB, publisher *model.Publisher, publisherId int) error
+	Update(db *gorm.DB, publisher *model.Publisher) error
+	Delete(db *gorm.DB, publisherId int) error
+	CountBooks(db *gorm.DB, count *int64, publisherId int) error
+	FindAll(db *gorm.DB, publishers *[]model.Publisher) error
+	FindByIds(db *gorm.DB, publishers *[]model.Publisher, publisherIds []int) error
+	FindByBookIds(db *gorm.DB, bookPublishers *[]model.BookPublisher, bookIds []int) error
+}
+
+type PublisherRepositoryImpl struct {
+}
+
+func NewPublisherRepository() PublisherRepository {
+	return &PublisherRepositoryImpl{}
+}
+
+func (r PublisherRepositoryImpl) Save(db *gorm.DB, publisher *model.Publisher) error {
+	query := `INSERT INTO publishers (name, created_at, updated_at) VALUES (?,?,?)`
+	result := db.Exec(query, publisher.Name, publisher.CreatedAt, publisher.UpdatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&publisher.Id).Error
+}
+
+func (r PublisherRepositoryImpl) Find(db *gorm.DB, publisher *model.Publisher, publisherId int) error {
+	result := db.Raw("SELECT * FROM publishers WHERE id = ?", publisherId).Scan(&publisher)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r PublisherRepositoryImpl) Update(db *gorm.DB, publisher *model.Publisher) error {
+	result := db.Exec("UPDATE publishers set name = ?, updated_at = ? WHERE id = ?", publisher.Name, publisher.UpdatedAt, publisher.Id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r PublisherRepositoryImpl) Delete(db *gorm.DB, publisherId int) error {
+	result := db.Exec("DELETE FROM publishers where id = ?", publisherId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// CountBooks counts the books linked to the publisher, deleted ones included
+// since their links are kept until they are purged.
+func (r PublisherRepositoryImpl) CountBooks(db *gorm.DB, count *int64, publisherId int) error {
+	return db.Raw("SELECT COUNT(*) from book_publishers WHERE publisher_id = ?", publisherId).Scan(count).Error
+}
+
+func (r PublisherRepositoryImpl) FindAll(db *gorm.DB, publishers *[]model.Publisher) error {
+	return db.Raw("SELECT * from publishers ORDER BY name").Scan(&publishers).Error
+}
+
+func (r PublisherRepositoryImpl) FindByIds(db *gorm.DB, publishers *[]model.Publisher, publisherIds []int) error {
+	if len(publisherIds) == 0 {
+		return nil
+	}
+	return db.Raw("SELECT * from publishers WHERE id IN ?", publisherIds).Scan(&publishers).Error
+}
+
+func (r PublisherRepositoryImpl) FindByBookIds(db *gorm.DB, bookPublishers *[]model.BookPublisher, bookIds []int) error {
+	if len(bookIds) == 0 {
+		return nil
+	}
+	query := `SELECT bp.book_id, p.* FROM publishers p
+	JOIN book_publishers bp ON bp.publisher_id = p.id
+	WHERE bp.book_id IN ?`
+	return db.Raw(query, bookIds).Scan(&bookPublishers).Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	Save(db *gorm.DB, recoveryCode *model.RecoveryCode) error
+	Consume(db *gorm.DB, userId int, codeHash string, now time.Time) error
+	DeleteByUserId(db *gorm.DB, userId int) error
+}
+
+type RecoveryCodeRepositoryImpl struct {
+}
+
+func NewRecoveryCodeRepository() RecoveryCodeRepository {
+	return &RecoveryCodeRepositoryImpl{}
+}
+
+func (r RecoveryCodeRepositoryImpl) Save(db *gorm.DB, recoveryCode *model.RecoveryCode) error {
+	query := `INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES (?,?,?)`
+	result := db.Exec(query, recoveryCode.UserId, recoveryCode.CodeHash, recoveryCode.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&recoveryCode.Id).Error
+}
+
+// Consume marks an unused recovery code of the user used. It fails if there
+// is no such code, so each code works once.
+func (r RecoveryCodeRepositoryImpl) Consume(db *gorm.DB, userId int, codeHash string, now time.Time) error {
+	result := db.Exec("UPDATE user_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL", now, userId, codeHash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r RecoveryCodeRepositoryImpl) DeleteByUserId(db *gorm.DB, userId int) error {
+	return db.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userId).Error
+}
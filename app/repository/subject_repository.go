@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+
+	"gorm.io/gorm"
+)
+
+type SubjectRepository interface {
+	Save(db *gorm.DB, subject *model.Subject) error
+	Find(db *gorm.DB, subject *model.Subject, subjectId int) error
+	Update(db *gorm.DB, subject *model.Subject) error
+	Delete(db *gorm.DB, subjectId int) error
+	CountBooks(db *gorm.DB, count *int64, subjectId int) error
+	FindAll(db *gorm.DB, subjects *[]model.Subject) error
+	FindByIds(db *gorm.DB, subjects *[]model.Subject, subjectIds []int) error
+	FindByBookIds(db *gorm.DB, bookSubjects *[]model.BookSubject, bookIds []int) error
+}
+
+type SubjectRepositoryImpl struct {
+}
+
+func NewSubjectRepository() SubjectRepository {
+	return &SubjectRepositoryImpl{}
+}
+
+func (r SubjectRepositoryImpl) Save(db *gorm.DB, subject *model.Subject) error {
+	query := `INSERT INTO subjects (name, created_at, updated_at) VALUES (?,?,?)`
+	result := db.Exec(query, subject.Name, subject.CreatedAt, subject.UpdatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&subject.Id).Error
+}
+
+func (r SubjectRepositoryImpl) Find(db *gorm.DB, subject *model.Subject, subjectId int) error {
+	result := db.Raw("SELECT * FROM subjects WHERE id = ?", subjectId).Scan(&subject)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r SubjectRepositoryImpl) Update(db *gorm.DB, subject *model.Subject) error {
+	result := db.Exec("UPDATE subjects set name = ?, updated_at = ? WHERE id = ?", subject.Name, subject.UpdatedAt, subject.Id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r SubjectRepositoryImpl) Delete(db *gorm.DB, subjectId int) error {
+	result := db.Exec("DELETE FROM subjects where id = ?", subjectId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// CountBooks counts the books linked to the subject, deleted ones included
+// since their links are kept until they are purged.
+func (r SubjectRepositoryImpl) CountBooks(db *gorm.DB, count *int64, subjectId int) error {
+	return db.Raw("SELECT COUNT(*) from book_subjects WHERE subject_id = ?", subjectId).Scan(count).Error
+}
+
+func (r SubjectRepositoryImpl) FindAll(db *gorm.DB, subjects *[]model.Subject) error {
+	return db.Raw("SELECT * from subjects ORDER BY name").Scan(&subjects).Error
+}
+
+func (r SubjectRepositoryImpl) FindByIds(db *gorm.DB, subjects *[]model.Subject, subjectIds []int) error {
+	if len(subjectIds) == 0 {
+		return nil
+	}
+	return db.Raw("SELECT * from subjects WHERE id IN ?", subjectIds).Scan(&subjects).Error
+}
+
+func (r SubjectRepositoryImpl) FindByBookIds(db *gorm.DB, bookSubjects *[]model.BookSubject, bookIds []int) error {
+	if len(bookIds) == 0 {
+		return nil
+	}
+	query := `SELECT bs.book_id, s.* FROM subjects s
+	JOIN book_subjects bs ON bs.subject_id = s.id
+	WHERE bs.book_id IN ?`
+	return db.Raw(query, bookIds).Scan(&bookSubjects).Error
+}
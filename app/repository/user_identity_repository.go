@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository interface {
+	Save(db *gorm.DB, identity *model.UserIdentity) error
+	FindBySubject(db *gorm.DB, identity *model.UserIdentity, issuer string, subject string) error
+	UpdateLogin(db *gorm.DB, identity *model.UserIdentity) error
+}
+
+type UserIdentityRepositoryImpl struct {
+}
+
+func NewUserIdentityRepository() UserIdentityRepository {
+	return &UserIdentityRepositoryImpl{}
+}
+
+func (r UserIdentityRepositoryImpl) Save(db *gorm.DB, identity *model.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, issuer, subject, email, created_at, last_login_at) VALUES (?,?,?,?,?,?)`
+	result := db.Exec(query, identity.UserId, identity.Issuer, identity.Subject, identity.Email, identity.CreatedAt, identity.LastLoginAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&identity.Id).Error
+}
+
+func (r UserIdentityRepositoryImpl) FindBySubject(db *gorm.DB, identity *model.UserIdentity, issuer string, subject string) error {
+	result := db.Raw("SELECT * from user_identities WHERE issuer = ? AND subject = ?", issuer, subject).Scan(&identity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// UpdateLogin stores the email the provider last reported and when the
+// identity was last used to log in.
+func (r UserIdentityRepositoryImpl) UpdateLogin(db *gorm.DB, identity *model.UserIdentity) error {
+	return db.Exec("UPDATE user_identities SET email = ?, last_login_at = ? WHERE id = ?", identity.Email, identity.LastLoginAt, identity.Id).Error
+}
@@ -3,6 +3,8 @@ package repository
 import (
 	"errors"
 	"kukuh/go-gin-library-project/app/model"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,8 +13,26 @@ type UserRepository interface {
 	Save(db *gorm.DB, user *model.User) error
 	FindByEmail(db *gorm.DB, userResult *model.User, email string) error
 	FindById(db *gorm.DB, userResult *model.User, userId int) error
+	FindByIdForUpdate(db *gorm.DB, userResult *model.User, userId int) error
+	CountByEmail(db *gorm.DB, count *int64, email string) error
+	FindByEmailWithDeleted(db *gorm.DB, users *[]model.User, email string) error
+	CountByRole(db *gorm.DB, count *int64, role string) error
+	CountByCardNumber(db *gorm.DB, count *int64, cardNumber string, exceptUserId int) error
+	FindAll(db *gorm.DB, users *[]model.User, total *int64, filter *model.UserFilter) error
 	Update(db *gorm.DB, user *model.User) error
+	UpdateAccount(db *gorm.DB, user *model.User) error
+	UpdateStatus(db *gorm.DB, user *model.User) error
+	VerifyEmail(db *gorm.DB, user *model.User) error
+	RequestEmailChange(db *gorm.DB, user *model.User) error
+	ChangeEmail(db *gorm.DB, user *model.User) error
+	ResetPassword(db *gorm.DB, user *model.User) error
+	UpdatePasswordHash(db *gorm.DB, userId int, passwordHash string) error
+	UpdateTwoFactor(db *gorm.DB, user *model.User) error
+	UpdateTotpStep(db *gorm.DB, userId int, step int64) error
 	Delete(db *gorm.DB, userId int) error
+	FindDeleted(db *gorm.DB, users *[]model.User) error
+	Restore(db *gorm.DB, userId int) error
+	Purge(db *gorm.DB, deletedBefore time.Time) (int64, error)
 }
 
 type UserRepositoryImpl struct {
@@ -23,43 +43,240 @@ func NewUserRepository() UserRepository {
 }
 
 func (r UserRepositoryImpl) Save(db *gorm.DB, user *model.User) error {
-	query := `INSERT INTO users (name, email, password, created_at, updated_at) VALUES (?,?,?,?,?)`
-	result := db.Exec(query, user.Name, user.Email, user.Password, user.CreatedAt, user.UpdatedAt)
+	query := `INSERT INTO users (name, email, password, role, patron_type, card_number, phone, address, status, created_at, updated_at) VALUES (?,?,?,?,?,?,?,?,?,?,?)`
+	result := db.Exec(query, user.Name, user.Email, user.Password, user.Role, user.PatronType, user.CardNumber, user.Phone, user.Address, user.Status, user.CreatedAt, user.UpdatedAt)
 
 	if result.RowsAffected == 0 {
 		return errors.New("failed to insert")
 	}
-	return nil
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&user.Id).Error
 }
 
 func (r UserRepositoryImpl) FindByEmail(db *gorm.DB, userResult *model.User, email string) error {
-	err := db.Raw("SELECT * from users where email = ?", email).Scan(&userResult).Error
-	if err != nil {
-		return err
+	result := db.Raw("SELECT * from users where email = ? AND deleted_at IS NULL", email).Scan(&userResult)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
 	}
 	return nil
 }
 
 func (r UserRepositoryImpl) FindById(db *gorm.DB, userResult *model.User, userId int) error {
-	err := db.Raw("SELECT * from users where id = ?", userId).Scan(&userResult).Error
+	result := db.Raw("SELECT * from users where id = ? AND deleted_at IS NULL", userId).Scan(&userResult)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// FindByIdForUpdate is FindById that also locks the row until the
+// transaction ends, serialising checks made on the user's behalf.
+func (r UserRepositoryImpl) FindByIdForUpdate(db *gorm.DB, userResult *model.User, userId int) error {
+	result := db.Raw("SELECT * from users where id = ? AND deleted_at IS NULL FOR UPDATE", userId).Scan(&userResult)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// CountByEmail counts users with the email, deleted ones included since
+// they still hold the address until purged.
+func (r UserRepositoryImpl) CountByEmail(db *gorm.DB, count *int64, email string) error {
+	return db.Raw("SELECT COUNT(*) from users where email = ?", email).Scan(count).Error
+}
+
+// FindByEmailWithDeleted looks up the user holding email, deleted ones
+// included like CountByEmail.
+func (r UserRepositoryImpl) FindByEmailWithDeleted(db *gorm.DB, users *[]model.User, email string) error {
+	return db.Raw("SELECT * from users where email = ?", email).Scan(users).Error
+}
+
+func (r UserRepositoryImpl) CountByRole(db *gorm.DB, count *int64, role string) error {
+	return db.Raw("SELECT COUNT(*) from users where role = ? AND deleted_at IS NULL", role).Scan(count).Error
+}
+
+// CountByCardNumber counts users other than exceptUserId holding the card,
+// deleted ones included like CountByEmail.
+func (r UserRepositoryImpl) CountByCardNumber(db *gorm.DB, count *int64, cardNumber string, exceptUserId int) error {
+	return db.Raw("SELECT COUNT(*) from users where card_number = ? AND id <> ?", cardNumber, exceptUserId).Scan(count).Error
+}
+
+// FindAll searches users that are not deleted. Query matches part of the
+// name or email, or the whole card number.
+func (r UserRepositoryImpl) FindAll(db *gorm.DB, users *[]model.User, total *int64, filter *model.UserFilter) error {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.Query != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Query) + "%"
+		conditions = append(conditions, "(name LIKE ? OR email LIKE ? OR card_number = ?)")
+		args = append(args, pattern, pattern, filter.Query)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, filter.Role)
+	}
+	if filter.PatronType != "" {
+		conditions = append(conditions, "patron_type = ?")
+		args = append(args, filter.PatronType)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	err := db.Raw("SELECT COUNT(*) from users"+where, args...).Scan(total).Error
 	if err != nil {
 		return err
 	}
-	return nil
+
+	args = append(args, filter.Limit, filter.Offset)
+	return db.Raw("SELECT * from users"+where+" ORDER BY name, id LIMIT ? OFFSET ?", args...).Scan(&users).Error
 }
 
+// likeEscaper escapes the LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_")
+
 func (r UserRepositoryImpl) Update(db *gorm.DB, user *model.User) error {
-	result := db.Exec("UPDATE users SET name = ?, password = ?, updated_at = ? where id = ?", user.Name, user.Password, user.UpdatedAt, user.Id)
+	result := db.Exec("UPDATE users SET name = ?, phone = ?, address = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.Name, user.Phone, user.Address, user.UpdatedAt, user.Id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
 	return nil
 }
 
-func (r UserRepositoryImpl) Delete(db *gorm.DB, userId int) error {
-	result := db.Exec("DELETE FROM users where id = ?", userId)
+// UpdateAccount saves the fields staff maintain for a patron.
+func (r UserRepositoryImpl) UpdateAccount(db *gorm.DB, user *model.User) error {
+	result := db.Exec("UPDATE users SET name = ?, phone = ?, address = ?, patron_type = ?, card_number = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.Name, user.Phone, user.Address, user.PatronType, user.CardNumber, user.UpdatedAt, user.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// UpdateStatus saves the status along with the session version, so that
+// suspending an account can sign it out.
+func (r UserRepositoryImpl) UpdateStatus(db *gorm.DB, user *model.User) error {
+	result := db.Exec("UPDATE users SET status = ?, status_reason = ?, session_version = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.Status, user.StatusReason, user.SessionVersion, user.UpdatedAt, user.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r UserRepositoryImpl) VerifyEmail(db *gorm.DB, user *model.User) error {
+	result := db.Exec("UPDATE users SET status = ?, email_verified_at = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.Status, user.EmailVerifiedAt, user.UpdatedAt, user.Id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
 		return errors.New("data not found")
 	}
 	return nil
 }
+
+func (r UserRepositoryImpl) RequestEmailChange(db *gorm.DB, user *model.User) error {
+	result := db.Exec("UPDATE users SET pending_email = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.PendingEmail, user.UpdatedAt, user.Id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r UserRepositoryImpl) ChangeEmail(db *gorm.DB, user *model.User) error {
+	result := db.Exec("UPDATE users SET email = ?, pending_email = ?, status = ?, email_verified_at = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.Email, user.PendingEmail, user.Status, user.EmailVerifiedAt, user.UpdatedAt, user.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// ResetPassword stores the new password and bumps the session version,
+// which invalidates every token issued before.
+func (r UserRepositoryImpl) ResetPassword(db *gorm.DB, user *model.User) error {
+	result := db.Exec("UPDATE users SET password = ?, status = ?, email_verified_at = ?, session_version = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.Password, user.Status, user.EmailVerifiedAt, user.SessionVersion, user.UpdatedAt, user.Id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// UpdatePasswordHash replaces the hash of an unchanged password, so unlike
+// ResetPassword it keeps existing sessions.
+func (r UserRepositoryImpl) UpdatePasswordHash(db *gorm.DB, userId int, passwordHash string) error {
+	return db.Exec("UPDATE users SET password = ? where id = ? AND deleted_at IS NULL", passwordHash, userId).Error
+}
+
+// UpdateTwoFactor stores the user's TOTP enrollment along with the session
+// version, so enabling two-factor authentication can sign out sessions that
+// were started without it.
+func (r UserRepositoryImpl) UpdateTwoFactor(db *gorm.DB, user *model.User) error {
+	result := db.Exec("UPDATE users SET totp_secret = ?, totp_enabled_at = ?, totp_last_step = ?, session_version = ?, updated_at = ? where id = ? AND deleted_at IS NULL", user.TotpSecret, user.TotpEnabledAt, user.TotpLastStep, user.SessionVersion, user.UpdatedAt, user.Id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) || result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// UpdateTotpStep records the time step of an accepted code. It fails if a
+// code for that step or a later one was already accepted.
+func (r UserRepositoryImpl) UpdateTotpStep(db *gorm.DB, userId int, step int64) error {
+	result := db.Exec("UPDATE users SET totp_last_step = ? where id = ? AND totp_last_step < ?", step, userId, step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("code already used")
+	}
+	return nil
+}
+
+func (r UserRepositoryImpl) Delete(db *gorm.DB, userId int) error {
+	result := db.Exec("UPDATE users SET deleted_at = ? where id = ? AND deleted_at IS NULL", time.Now(), userId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r UserRepositoryImpl) FindDeleted(db *gorm.DB, users *[]model.User) error {
+	return db.Raw("SELECT * from users where deleted_at IS NOT NULL ORDER BY deleted_at DESC").Scan(&users).Error
+}
+
+func (r UserRepositoryImpl) Restore(db *gorm.DB, userId int) error {
+	result := db.Exec("UPDATE users SET deleted_at = NULL, updated_at = ? where id = ? AND deleted_at IS NOT NULL", time.Now(), userId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// Purge permanently removes users deleted before the given time. Users still
+// referenced by borrowings are kept so circulation history stays intact.
+func (r UserRepositoryImpl) Purge(db *gorm.DB, deletedBefore time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?
+	AND NOT EXISTS (SELECT 1 FROM borrowings WHERE borrowings.user_id = users.id)`
+	result := db.Exec(query, deletedBefore)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserTokenRepository interface {
+	Save(db *gorm.DB, userToken *model.UserToken) error
+	FindByHash(db *gorm.DB, userToken *model.UserToken, purpose string, tokenHash string) error
+	Consume(db *gorm.DB, userTokenId int, now time.Time) error
+	InvalidateByUserId(db *gorm.DB, userId int, purpose string, now time.Time) error
+	CountSince(db *gorm.DB, count *int64, userId int, purpose string, since time.Time) error
+}
+
+type UserTokenRepositoryImpl struct {
+}
+
+func NewUserTokenRepository() UserTokenRepository {
+	return &UserTokenRepositoryImpl{}
+}
+
+func (r UserTokenRepositoryImpl) Save(db *gorm.DB, userToken *model.UserToken) error {
+	query := `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at) VALUES (?,?,?,?,?)`
+	result := db.Exec(query, userToken.UserId, userToken.Purpose, userToken.TokenHash, userToken.ExpiresAt, userToken.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&userToken.Id).Error
+}
+
+func (r UserTokenRepositoryImpl) FindByHash(db *gorm.DB, userToken *model.UserToken, purpose string, tokenHash string) error {
+	result := db.Raw("SELECT * from user_tokens WHERE purpose = ? AND token_hash = ?", purpose, tokenHash).Scan(&userToken)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+// Consume marks the token used. It fails if the token was already used, so
+// of two concurrent requests with the same token only one succeeds.
+func (r UserTokenRepositoryImpl) Consume(db *gorm.DB, userTokenId int, now time.Time) error {
+	result := db.Exec("UPDATE user_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", now, userTokenId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("token already used")
+	}
+	return nil
+}
+
+// InvalidateByUserId retires the user's outstanding tokens for purpose.
+func (r UserTokenRepositoryImpl) InvalidateByUserId(db *gorm.DB, userId int, purpose string, now time.Time) error {
+	return db.Exec("UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL", now, userId, purpose).Error
+}
+
+func (r UserTokenRepositoryImpl) CountSince(db *gorm.DB, count *int64, userId int, purpose string, since time.Time) error {
+	return db.Raw("SELECT COUNT(*) from user_tokens WHERE user_id = ? AND purpose = ? AND created_at >= ?", userId, purpose, since).Scan(count).Error
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	SaveSubscription(db *gorm.DB, subscription *model.WebhookSubscription) error
+	UpdateSubscription(db *gorm.DB, subscription *model.WebhookSubscription) error
+	DeleteSubscription(db *gorm.DB, subscriptionId int) error
+	FindSubscription(db *gorm.DB, subscription *model.WebhookSubscription, subscriptionId int) error
+	FindAllSubscriptions(db *gorm.DB, subscriptions *[]model.WebhookSubscription) error
+	FindActiveSubscriptions(db *gorm.DB, subscriptions *[]model.WebhookSubscription) error
+	SaveDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error
+	UpdateDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error
+	FindDelivery(db *gorm.DB, delivery *model.WebhookDelivery, deliveryId int) error
+	FindDeliveries(db *gorm.DB, deliveries *[]model.WebhookDelivery, total *int64, filter *model.WebhookDeliveryFilter) error
+	FindDueDeliveries(db *gorm.DB, deliveries *[]model.WebhookDelivery, now time.Time, limit int) error
+}
+
+type WebhookRepositoryImpl struct {
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return &WebhookRepositoryImpl{}
+}
+
+func (r WebhookRepositoryImpl) SaveSubscription(db *gorm.DB, subscription *model.WebhookSubscription) error {
+	query := `INSERT INTO webhook_subscriptions (url, secret, events, active, created_at, updated_at) 
+	VALUES (?,?,?,?,?,?)`
+	result := db.Exec(query, subscription.Url, subscription.Secret, subscription.Events, subscription.Active, subscription.CreatedAt, subscription.UpdatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&subscription.Id).Error
+}
+
+func (r WebhookRepositoryImpl) UpdateSubscription(db *gorm.DB, subscription *model.WebhookSubscription) error {
+	result := db.Exec("UPDATE webhook_subscriptions SET url = ?, secret = ?, events = ?, active = ?, updated_at = ? WHERE id = ?",
+		subscription.Url, subscription.Secret, subscription.Events, subscription.Active, subscription.UpdatedAt, subscription.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r WebhookRepositoryImpl) DeleteSubscription(db *gorm.DB, subscriptionId int) error {
+	result := db.Exec("DELETE FROM webhook_subscriptions WHERE id = ?", subscriptionId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r WebhookRepositoryImpl) FindSubscription(db *gorm.DB, subscription *model.WebhookSubscription, subscriptionId int) error {
+	result := db.Raw("SELECT * FROM webhook_subscriptions WHERE id = ?", subscriptionId).Scan(&subscription)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r WebhookRepositoryImpl) FindAllSubscriptions(db *gorm.DB, subscriptions *[]model.WebhookSubscription) error {
+	return db.Raw("SELECT * FROM webhook_subscriptions ORDER BY id").Scan(&subscriptions).Error
+}
+
+func (r WebhookRepositoryImpl) FindActiveSubscriptions(db *gorm.DB, subscriptions *[]model.WebhookSubscription) error {
+	return db.Raw("SELECT * FROM webhook_subscriptions WHERE active = TRUE").Scan(&subscriptions).Error
+}
+
+func (r WebhookRepositoryImpl) SaveDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event, payload, status, attempts, next_attempt_at, created_at) 
+	VALUES (?,?,?,?,?,?,?,?)`
+	result := db.Exec(query, delivery.SubscriptionId, delivery.EventId, delivery.Event, delivery.Payload, delivery.Status, delivery.Attempts,
+		delivery.NextAttemptAt, delivery.CreatedAt)
+
+	if result.RowsAffected == 0 {
+		return errors.New("failed to insert")
+	}
+	return db.Raw("SELECT LAST_INSERT_ID()").Scan(&delivery.Id).Error
+}
+
+func (r WebhookRepositoryImpl) UpdateDelivery(db *gorm.DB, delivery *model.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, response_body = ?, 
+	error = ?, delivered_at = ? WHERE id = ?`
+	result := db.Exec(query, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.ResponseStatus, delivery.ResponseBody,
+		delivery.Error, delivery.DeliveredAt, delivery.Id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r WebhookRepositoryImpl) FindDelivery(db *gorm.DB, delivery *model.WebhookDelivery, deliveryId int) error {
+	result := db.Raw("SELECT * FROM webhook_deliveries WHERE id = ?", deliveryId).Scan(&delivery)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("data not found")
+	}
+	return nil
+}
+
+func (r WebhookRepositoryImpl) FindDeliveries(db *gorm.DB, deliveries *[]model.WebhookDelivery, total *int64, filter *model.WebhookDeliveryFilter) error {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	if filter.SubscriptionId != 0 {
+		conditions = append(conditions, "subscription_id = ?")
+		args = append(args, filter.SubscriptionId)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	err := db.Raw("SELECT COUNT(*) from webhook_deliveries"+where, args...).Scan(total).Error
+	if err != nil {
+		return err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	return db.Raw("SELECT * from webhook_deliveries"+where+" ORDER BY id DESC LIMIT ? OFFSET ?", args...).Scan(&deliveries).Error
+}
+
+func (r WebhookRepositoryImpl) FindDueDeliveries(db *gorm.DB, deliveries *[]model.WebhookDelivery, now time.Time, limit int) error {
+	return db.Raw("SELECT * FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?",
+		model.WebhookDeliveryPending, now, limit).Scan(&deliveries).Error
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejects(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) accepted the expression", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2026, time.January, 14, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.January, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.January, 14, 10, 30, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, time.January, 14, 10, 25, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, time.January, 15, 2, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, time.January, 14, 13, 0, 0, 0, time.UTC)},
+		{"17,45 10 * * *", time.Date(2026, time.January, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 3 *", time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1-5", time.Date(2026, time.January, 15, 8, 30, 0, 0, time.UTC)},
+		// 0 and 7 are both Sunday.
+		{"0 0 * * 0", time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		// With both day fields restricted, either one matching is enough:
+		// Friday the 16th comes before the 20th.
+		{"0 0 20 * 5", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		// A wildcard day of month leaves the weekday alone in charge.
+		{"0 0 * * 5", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 4 *", time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			schedule, err := ParseCron(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(from); !got.Equal(test.want) {
+				t.Errorf("Next = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNextIsStrictlyAfter(t *testing.T) {
+	schedule, err := ParseCron("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, time.January, 14, 2, 0, 0, 0, time.UTC)
+	if got, want := schedule.Next(at), at.AddDate(0, 0, 1); !got.Equal(want) {
+		t.Errorf("Next at a matching minute = %v, want %v", got, want)
+	}
+}
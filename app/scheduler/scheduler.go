@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/helper"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultLockTimeout = time.Hour
+
+var ErrUnknownJob = errors.New("unknown job")
+
+type Job interface {
+	Run(ctx context.Context) error
+}
+
+type JobInfo struct {
+	Name      string
+	Spec      string
+	NextRunAt time.Time
+}
+
+type entry struct {
+	name     string
+	spec     string
+	schedule *Schedule
+	job      Job
+}
+
+// Scheduler runs registered jobs on their cron schedules. Every run is
+// recorded in job_runs, keyed by job and scheduled time, so when several
+// replicas fire the same tick only the first to insert the row runs it. A
+// lease in job_locks additionally keeps runs of one job from overlapping,
+// which covers manual triggers and runs that outlast their interval.
+type Scheduler struct {
+	JobRunRepository repository.JobRunRepository
+	DB               *gorm.DB
+	Instance         string
+	LockTimeout      time.Duration
+	entries          []*entry
+}
+
+func NewScheduler(jobRunRepository repository.JobRunRepository, DB *gorm.DB) *Scheduler {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return &Scheduler{
+		JobRunRepository: jobRunRepository,
+		DB:               DB,
+		Instance:         hostname + "-" + strconv.Itoa(os.Getpid()),
+		LockTimeout:      defaultLockTimeout,
+	}
+}
+
+// Register adds a job under name with a five field cron spec, evaluated in
+// the server's local time zone. It must be called before Start.
+func (s *Scheduler) Register(name string, spec string, job Job) error {
+	if s.find(name) != nil {
+		return fmt.Errorf("job %q already registered", name)
+	}
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.entries = append(s.entries, &entry{name: name, spec: spec, schedule: schedule, job: job})
+	return nil
+}
+
+func (s *Scheduler) Jobs() []JobInfo {
+	now := time.Now()
+	jobs := []JobInfo{}
+	for _, e := range s.entries {
+		jobs = append(jobs, JobInfo{Name: e.name, Spec: e.spec, NextRunAt: e.schedule.Next(now)})
+	}
+	return jobs
+}
+
+// Start runs jobs as they come due until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for {
+		var next time.Time
+		now := time.Now()
+		for _, e := range s.entries {
+			at := e.schedule.Next(now)
+			if !at.IsZero() && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+		if next.IsZero() {
+			<-ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		for _, e := range s.entries {
+			if e.schedule.Next(next.Add(-time.Minute)).Equal(next) {
+				go s.runScheduled(ctx, e, next)
+			}
+		}
+	}
+}
+
+// Trigger starts a run of the named job outside its schedule and returns
+// once the run is recorded; the job itself continues in the background.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (*model.JobRun, error) {
+	e := s.find(name)
+	if e == nil {
+		return nil, ErrUnknownJob
+	}
+
+	var actorId *int
+	if id, err := strconv.Atoi(helper.AuthId(ctx)); err == nil {
+		actorId = &id
+	}
+
+	jobRun, owner, err := s.claim(e, time.Now(), model.JobTriggerManual, actorId)
+	if err != nil {
+		return nil, err
+	}
+	if jobRun.Status == model.JobRunStatusRunning {
+		// Keep the caller's identity for auditing but not its cancellation,
+		// since the request ends before the job does.
+		go s.execute(context.WithoutCancel(ctx), e, jobRun, owner)
+	}
+	return jobRun, nil
+}
+
+func (s *Scheduler) runScheduled(ctx context.Context, e *entry, scheduledAt time.Time) {
+	jobRun, owner, err := s.claim(e, scheduledAt, model.JobTriggerSchedule, nil)
+	if errors.Is(err, repository.ErrJobRunClaimed) {
+		return
+	}
+	if err != nil {
+		log.Printf("scheduler: %s: %v", e.name, err)
+		return
+	}
+	if jobRun.Status == model.JobRunStatusRunning {
+		s.execute(ctx, e, jobRun, owner)
+	}
+}
+
+// claim records the run and takes the job's lease. When the lease is held
+// elsewhere the run is recorded as skipped instead.
+func (s *Scheduler) claim(e *entry, scheduledAt time.Time, triggeredBy string, actorId *int) (*model.JobRun, string, error) {
+	jobRun := model.JobRun{
+		JobName:     e.name,
+		ScheduledAt: scheduledAt,
+		TriggeredBy: triggeredBy,
+		ActorId:     actorId,
+		Status:      model.JobRunStatusRunning,
+		Instance:    s.Instance,
+		StartedAt:   time.Now(),
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.JobRunRepository.Save(tx, &jobRun)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	owner := s.Instance + "#" + strconv.Itoa(jobRun.Id)
+	now := time.Now()
+	locked, err := s.JobRunRepository.TryLock(s.DB, e.name, owner, now, now.Add(s.LockTimeout))
+	if err == nil && !locked {
+		err = errors.New("previous run still in progress")
+	}
+	if err != nil {
+		jobRun.Status = model.JobRunStatusSkipped
+		jobRun.Error = err.Error()
+		jobRun.FinishedAt = &now
+		if err := s.JobRunRepository.Finish(s.DB, &jobRun); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return &jobRun, owner, nil
+}
+
+func (s *Scheduler) execute(ctx context.Context, e *entry, jobRun *model.JobRun, owner string) {
+	err := s.safeRun(ctx, e.job)
+
+	now := time.Now()
+	jobRun.Status = model.JobRunStatusSucceeded
+	jobRun.FinishedAt = &now
+	if err != nil {
+		jobRun.Status = model.JobRunStatusFailed
+		jobRun.Error = err.Error()
+		log.Printf("scheduler: %s: %v", e.name, err)
+	}
+
+	if err := s.JobRunRepository.Finish(s.DB, jobRun); err != nil {
+		log.Printf("scheduler: %s: %v", e.name, err)
+	}
+	if err := s.JobRunRepository.Unlock(s.DB, e.name, owner, now); err != nil {
+		log.Printf("scheduler: %s: %v", e.name, err)
+	}
+}
+
+func (s *Scheduler) safeRun(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) find(name string) *entry {
+	for _, e := range s.entries {
+		if e.name == name {
+			return e
+		}
+	}
+	return nil
+}
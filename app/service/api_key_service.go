@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/helper/token"
+	"kukuh/go-gin-library-project/response"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval is how stale last_used_at may get before a request
+// with the key updates it.
+const apiKeyTouchInterval = time.Minute
+
+type ApiKeyService interface {
+	Create(ctx context.Context, request *web.ApiKeyCreateRequest) (*web.ApiKeyResponse, *response.CustomError)
+	FindAll(ctx context.Context) ([]web.ApiKeyResponse, *response.CustomError)
+	Revoke(ctx context.Context, apiKeyId int) *response.CustomError
+	Authenticate(ctx context.Context, key string) (*model.ApiKey, *response.CustomError)
+}
+
+type ApiKeyServiceImpl struct {
+	ApiKeyRepository   repository.ApiKeyRepository
+	AuditLogRepository repository.AuditLogRepository
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+}
+
+func NewApiKeyService(apiKeyRepository repository.ApiKeyRepository, auditLogRepository repository.AuditLogRepository, DB *gorm.DB, validate *validator.Validate) ApiKeyService {
+	return &ApiKeyServiceImpl{
+		ApiKeyRepository:   apiKeyRepository,
+		AuditLogRepository: auditLogRepository,
+		DB:                 DB,
+		Validate:           validate,
+	}
+}
+
+// Create issues a key of the form lib_<prefix>_<secret>. Only the prefix,
+// which identifies the key, and a hash of the whole key are stored, so the
+// key itself is shown once in the response.
+func (s *ApiKeyServiceImpl) Create(ctx context.Context, request *web.ApiKeyCreateRequest) (*web.ApiKeyResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	now := time.Now()
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(now) {
+		return nil, response.BadRequestError("expires_at must be in the future")
+	}
+
+	scopes, err := json.Marshal(request.Scopes)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	prefix := helper.RandomHex(4)
+	key := model.ApiKeyPrefix + prefix + "_" + helper.RandomHex(32)
+	apiKey := model.ApiKey{
+		Name:      request.Name,
+		Prefix:    prefix,
+		KeyHash:   token.HashOpaqueToken(key),
+		Scopes:    string(scopes),
+		ExpiresAt: request.ExpiresAt,
+		CreatedAt: now,
+	}
+	if actorId, err := strconv.Atoi(helper.AuthId(ctx)); err == nil {
+		apiKey.CreatedBy = &actorId
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.ApiKeyRepository.Save(tx, &apiKey)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityApiKey, apiKey.Id, nil, &apiKey)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	apiKeyResponse := toApiKeyResponse(apiKey)
+	apiKeyResponse.Key = key
+	return &apiKeyResponse, nil
+}
+
+func (s *ApiKeyServiceImpl) FindAll(ctx context.Context) ([]web.ApiKeyResponse, *response.CustomError) {
+	var apiKeys []model.ApiKey
+	err := s.ApiKeyRepository.FindAll(s.DB, &apiKeys)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	apiKeyResponses := []web.ApiKeyResponse{}
+	for _, apiKey := range apiKeys {
+		apiKeyResponses = append(apiKeyResponses, toApiKeyResponse(apiKey))
+	}
+
+	return apiKeyResponses, nil
+}
+
+func (s *ApiKeyServiceImpl) Revoke(ctx context.Context, apiKeyId int) *response.CustomError {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.ApiKeyRepository.Revoke(tx, apiKeyId, time.Now())
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionRevoke, model.AuditEntityApiKey, apiKeyId, nil, nil)
+	})
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+
+	return nil
+}
+
+// Authenticate looks the key up by its prefix and checks it against the
+// stored hash. Revoked and expired keys are rejected with the same message
+// as unknown ones.
+func (s *ApiKeyServiceImpl) Authenticate(ctx context.Context, key string) (*model.ApiKey, *response.CustomError) {
+	prefix, _, ok := strings.Cut(strings.TrimPrefix(key, model.ApiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(key, model.ApiKeyPrefix) {
+		return nil, response.UnauthorizedError("Invalid API key")
+	}
+
+	var apiKey model.ApiKey
+	err := s.ApiKeyRepository.FindByPrefix(s.DB, &apiKey, prefix)
+	if err != nil {
+		return nil, response.UnauthorizedError("Invalid API key")
+	}
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(token.HashOpaqueToken(key))) != 1 ||
+		apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt)) {
+		return nil, response.UnauthorizedError("Invalid API key")
+	}
+
+	err = s.ApiKeyRepository.Touch(s.DB, apiKey.Id, now, now.Add(-apiKeyTouchInterval))
+	if err != nil {
+		log.Printf("api key service: record use of key %d: %v", apiKey.Id, err)
+	}
+
+	return &apiKey, nil
+}
+
+func toApiKeyResponse(apiKey model.ApiKey) web.ApiKeyResponse {
+	return web.ApiKeyResponse{
+		Id:         apiKey.Id,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.ScopeList(),
+		CreatedBy:  apiKey.CreatedBy,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		RevokedAt:  apiKey.RevokedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
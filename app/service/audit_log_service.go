@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/response"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogService interface {
+	FindAll(ctx context.Context, filter *web.AuditLogFilter) (*web.PageResponse, *response.CustomError)
+}
+
+type AuditLogServiceImpl struct {
+	AuditLogRepository repository.AuditLogRepository
+	DB                 *gorm.DB
+}
+
+func NewAuditLogService(auditLogRepository repository.AuditLogRepository, DB *gorm.DB) AuditLogService {
+	return &AuditLogServiceImpl{
+		AuditLogRepository: auditLogRepository,
+		DB:                 DB,
+	}
+}
+
+func (s *AuditLogServiceImpl) FindAll(ctx context.Context, filter *web.AuditLogFilter) (*web.PageResponse, *response.CustomError) {
+	page := filter.PageRequest.Normalize()
+
+	var auditLogs []model.AuditLog
+	var total int64
+	err := s.AuditLogRepository.FindAll(s.DB, &auditLogs, &total, &model.AuditLogFilter{
+		ActorId:  filter.ActorId,
+		Action:   filter.Action,
+		Entity:   filter.Entity,
+		EntityId: filter.EntityId,
+		From:     filter.From,
+		To:       filter.To,
+		Limit:    page.Limit,
+		Offset:   page.Offset(),
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	auditLogResponses := []web.AuditLogResponse{}
+	for _, auditLog := range auditLogs {
+		auditLogResponse := web.AuditLogResponse{
+			Id:        auditLog.Id,
+			ActorId:   auditLog.ActorId,
+			Action:    auditLog.Action,
+			Entity:    auditLog.Entity,
+			EntityId:  auditLog.EntityId,
+			Changes:   json.RawMessage(auditLog.Changes),
+			RequestId: auditLog.RequestId,
+			CreatedAt: auditLog.CreatedAt,
+		}
+		auditLogResponses = append(auditLogResponses, auditLogResponse)
+	}
+
+	return &web.PageResponse{
+		Items: auditLogResponses,
+		Page:  page.Page,
+		Limit: page.Limit,
+		Total: total,
+	}, nil
+}
+
+// recordAudit appends an audit log entry for a mutation. It should be called
+// with the same transaction as the mutation so both commit or neither does.
+// before and after are model values; only the fields that differ are stored.
+func recordAudit(ctx context.Context, db *gorm.DB, auditLogRepository repository.AuditLogRepository, action string, entity string, entityId int, before any, after any) error {
+	changes, err := json.Marshal(diffFields(before, after))
+	if err != nil {
+		return err
+	}
+
+	auditLog := model.AuditLog{
+		Action:    action,
+		Entity:    entity,
+		EntityId:  entityId,
+		Changes:   string(changes),
+		RequestId: helper.RequestId(ctx),
+		CreatedAt: time.Now(),
+	}
+	if actorId, err := strconv.Atoi(helper.AuthId(ctx)); err == nil {
+		auditLog.ActorId = &actorId
+	}
+
+	return auditLogRepository.Save(db, &auditLog)
+}
+
+var redactedFields = map[string]bool{
+	"Password":   true,
+	"TotpSecret": true,
+	"KeyHash":    true,
+}
+
+var ignoredFields = map[string]bool{
+	"UpdatedAt": true,
+}
+
+type fieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+func diffFields(before any, after any) map[string]fieldChange {
+	beforeFields := toFieldMap(before)
+	afterFields := toFieldMap(after)
+
+	changes := map[string]fieldChange{}
+	for name := range mergeKeys(beforeFields, afterFields) {
+		if ignoredFields[name] {
+			continue
+		}
+		beforeValue, afterValue := beforeFields[name], afterFields[name]
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+		if redactedFields[name] {
+			beforeValue, afterValue = redact(beforeValue), redact(afterValue)
+		}
+		changes[name] = fieldChange{Before: beforeValue, After: afterValue}
+	}
+	return changes
+}
+
+func toFieldMap(value any) map[string]any {
+	fields := map[string]any{}
+	if value == nil {
+		return fields
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+		return fields
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func mergeKeys(a map[string]any, b map[string]any) map[string]bool {
+	keys := map[string]bool{}
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
+
+func redact(value any) any {
+	if value == nil {
+		return nil
+	}
+	return "[redacted]"
+}
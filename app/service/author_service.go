@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type AuthorService interface {
+	Create(ctx context.Context, request *web.AuthorCreate) (*web.AuthorResponse, *response.CustomError)
+	Update(ctx context.Context, request *web.AuthorUpdate) (*web.AuthorResponse, *response.CustomError)
+	Find(ctx context.Context, authorId int) (*web.AuthorResponse, *response.CustomError)
+	Delete(ctx context.Context, authorId int) *response.CustomError
+	FindAll(ctx context.Context) ([]web.AuthorResponse, *response.CustomError)
+}
+
+type AuthorServiceImpl struct {
+	AuthorRepository   repository.AuthorRepository
+	AuditLogRepository repository.AuditLogRepository
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+}
+
+func NewAuthorService(authorRepository repository.AuthorRepository, auditLogRepository repository.AuditLogRepository, DB *gorm.DB, validate *validator.Validate) AuthorService {
+	return &AuthorServiceImpl{
+		AuthorRepository:   authorRepository,
+		AuditLogRepository: auditLogRepository,
+		DB:                 DB,
+		Validate:           validate,
+	}
+}
+
+func (s *AuthorServiceImpl) Create(ctx context.Context, request *web.AuthorCreate) (*web.AuthorResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	author := model.Author{
+		Name:      request.Name,
+		Bio:       request.Bio,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.AuthorRepository.Save(tx, &author)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityAuthor, author.Id, nil, &author)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	authorResponse := web.AuthorResponse{
+		Id:   author.Id,
+		Name: author.Name,
+		Bio:  author.Bio,
+	}
+
+	return &authorResponse, nil
+}
+
+func (s *AuthorServiceImpl) Update(ctx context.Context, request *web.AuthorUpdate) (*web.AuthorResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var author model.Author
+
+	err = s.AuthorRepository.Find(s.DB, &author, request.Id)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	before := author
+	author.Name = request.Name
+	author.Bio = request.Bio
+	author.UpdatedAt = time.Now()
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.AuthorRepository.Update(tx, &author)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityAuthor, author.Id, &before, &author)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	authorResponse := web.AuthorResponse{
+		Id:   author.Id,
+		Name: author.Name,
+		Bio:  author.Bio,
+	}
+
+	return &authorResponse, nil
+}
+
+func (s *AuthorServiceImpl) Find(ctx context.Context, authorId int) (*web.AuthorResponse, *response.CustomError) {
+	var author model.Author
+
+	err := s.AuthorRepository.Find(s.DB, &author, authorId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	authorResponse := web.AuthorResponse{
+		Id:   author.Id,
+		Name: author.Name,
+		Bio:  author.Bio,
+	}
+
+	return &authorResponse, nil
+}
+
+func (s *AuthorServiceImpl) Delete(ctx context.Context, authorId int) *response.CustomError {
+	var author model.Author
+
+	err := s.AuthorRepository.Find(s.DB, &author, authorId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+
+	linked := false
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var books int64
+		err := s.AuthorRepository.CountBooks(tx, &books, author.Id)
+		if err != nil {
+			return err
+		}
+		if books > 0 {
+			linked = true
+			return errors.New("author is linked to books")
+		}
+		err = s.AuthorRepository.Delete(tx, author.Id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionDelete, model.AuditEntityAuthor, author.Id, &author, nil)
+	})
+	if linked {
+		return response.ConflictError("Author is linked to books, including deleted ones, and cannot be deleted until they are unlinked or purged")
+	}
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	return nil
+}
+
+func (s *AuthorServiceImpl) FindAll(ctx context.Context) ([]web.AuthorResponse, *response.CustomError) {
+	var authors []model.Author
+	err := s.AuthorRepository.FindAll(s.DB, &authors)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	authorResponses := []web.AuthorResponse{}
+	for _, author := range authors {
+		authorResponse := web.AuthorResponse{
+			Id:   author.Id,
+			Name: author.Name,
+			Bio:  author.Bio,
+		}
+		authorResponses = append(authorResponses, authorResponse)
+	}
+
+	return authorResponses, nil
+}
@@ -1,11 +1,22 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
 	"kukuh/go-gin-library-project/app/model"
 	"kukuh/go-gin-library-project/app/repository"
 	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/helper/storage"
 	"kukuh/go-gin-library-project/response"
+	"net/http"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -15,22 +26,56 @@ import (
 type BookService interface {
 	Create(ctx context.Context, request *web.BookCreate) (*web.BookResponse, *response.CustomError)
 	Update(ctx context.Context, request *web.BookUpdate) (*web.BookResponse, *response.CustomError)
+	Patch(ctx context.Context, bookId int, patch []byte, version int) (*web.BookResponse, *response.CustomError)
 	Find(ctx context.Context, bookId int) (*web.BookResponse, *response.CustomError)
 	Delete(ctx context.Context, bookId int) *response.CustomError
-	FindAll(ctx context.Context) ([]web.BookResponse, *response.CustomError)
+	FindAll(ctx context.Context, filter *web.BookFilter) ([]web.BookResponse, *response.CustomError)
+	UploadCover(ctx context.Context, bookId int, data []byte) (*web.BookResponse, *response.CustomError)
+	FindCover(ctx context.Context, bookId int, size string) ([]byte, string, *response.CustomError)
+	FindDeleted(ctx context.Context) ([]web.BookResponse, *response.CustomError)
+	Restore(ctx context.Context, bookId int) (*web.BookResponse, *response.CustomError)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, *response.CustomError)
+}
+
+const MaxCoverSize = 5 << 20
+
+// MaxCoverDimension bounds the width and height of an uploaded cover. A
+// small file can declare huge dimensions, and decoding allocates for every
+// pixel, so the header is checked before decoding.
+const MaxCoverDimension = 4096
+
+const CoverSizeOriginal = "original"
+
+var coverSizes = map[string]image.Point{
+	"medium": {X: 400, Y: 600},
+	"small":  {X: 120, Y: 180},
 }
 
 type BookServiceImpl struct {
-	BookRepository repository.BookRepository
-	DB             *gorm.DB
-	Validate       *validator.Validate
+	BookRepository      repository.BookRepository
+	AuthorRepository    repository.AuthorRepository
+	PublisherRepository repository.PublisherRepository
+	SubjectRepository   repository.SubjectRepository
+	BorrowingRepository repository.BorrowingRepository
+	AuditLogRepository  repository.AuditLogRepository
+	OutboxRepository    repository.OutboxRepository
+	Storage             storage.BlobStorage
+	DB                  *gorm.DB
+	Validate            *validator.Validate
 }
 
-func NewBookService(bookRepository repository.BookRepository, DB *gorm.DB, validate *validator.Validate) BookService {
+func NewBookService(bookRepository repository.BookRepository, authorRepository repository.AuthorRepository, publisherRepository repository.PublisherRepository, subjectRepository repository.SubjectRepository, borrowingRepository repository.BorrowingRepository, auditLogRepository repository.AuditLogRepository, outboxRepository repository.OutboxRepository, blobStorage storage.BlobStorage, DB *gorm.DB, validate *validator.Validate) BookService {
 	return &BookServiceImpl{
-		BookRepository: bookRepository,
-		DB:             DB,
-		Validate:       validate,
+		BookRepository:      bookRepository,
+		AuthorRepository:    authorRepository,
+		PublisherRepository: publisherRepository,
+		SubjectRepository:   subjectRepository,
+		BorrowingRepository: borrowingRepository,
+		AuditLogRepository:  auditLogRepository,
+		OutboxRepository:    outboxRepository,
+		Storage:             blobStorage,
+		DB:                  DB,
+		Validate:            validate,
 	}
 }
 
@@ -40,31 +85,52 @@ func (s *BookServiceImpl) Create(ctx context.Context, request *web.BookCreate) (
 		return nil, response.BadRequestError(err.Error())
 	}
 
+	err = s.checkLinks(request.AuthorIds, request.PublisherIds, request.SubjectIds)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	if customErr := s.checkIsbn(request.Isbn, 0); customErr != nil {
+		return nil, customErr
+	}
+
 	book := model.Book{
-		Title:           request.Title,
-		Author:          request.Author,
-		Isbn:            request.Isbn,
-		PublicationYear: request.PublicationYear,
-		Quantity:        request.Quantity,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+		Title:                request.Title,
+		Isbn:                 request.Isbn,
+		Category:             bookCategory(request.Category),
+		PublicationYear:      request.PublicationYear,
+		Quantity:             request.Quantity,
+		ReplacementCostCents: request.ReplacementCostCents,
+		Version:              1,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
 	}
 
-	err = s.BookRepository.Save(s.DB, &book)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.BookRepository.Save(tx, &book)
+		if err != nil {
+			return err
+		}
+		err = s.updateLinks(tx, book.Id, request.AuthorIds, request.PublisherIds, request.SubjectIds)
+		if err != nil {
+			return err
+		}
+		after := newBookSnapshot(book, request.AuthorIds, request.PublisherIds, request.SubjectIds)
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityBook, book.Id, nil, after)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventBookCreated, model.AuditEntityBook, book.Id, newBookEventData(after))
+	})
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	BookResponse := web.BookResponse{
-		Id:              book.Id,
-		Title:           book.Title,
-		Author:          book.Author,
-		Isbn:            book.Isbn,
-		PublicationYear: book.PublicationYear,
-		Quantity:        book.Quantity,
+	bookResponses, err := s.toBookResponses([]model.Book{book})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
 	}
 
-	return &BookResponse, nil
+	return &bookResponses[0], nil
 }
 
 func (s *BookServiceImpl) Update(ctx context.Context, request *web.BookUpdate) (*web.BookResponse, *response.CustomError) {
@@ -80,27 +146,119 @@ func (s *BookServiceImpl) Update(ctx context.Context, request *web.BookUpdate) (
 		return nil, response.NotFoundError(err.Error())
 	}
 
+	return s.update(ctx, book, request)
+}
+
+func (s *BookServiceImpl) Patch(ctx context.Context, bookId int, patch []byte, version int) (*web.BookResponse, *response.CustomError) {
+	var book model.Book
+
+	err := s.BookRepository.Find(s.DB, &book, bookId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	current, err := s.snapshot(book)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+	document, err := json.Marshal(web.BookUpdate{
+		Id:                   book.Id,
+		Title:                book.Title,
+		AuthorIds:            current.AuthorIds,
+		PublisherIds:         current.PublisherIds,
+		SubjectIds:           current.SubjectIds,
+		Isbn:                 book.Isbn,
+		Category:             book.Category,
+		PublicationYear:      book.PublicationYear,
+		Quantity:             book.Quantity,
+		ReplacementCostCents: book.ReplacementCostCents,
+	})
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	patched, keys, err := helper.MergePatch(document, patch)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var request web.BookUpdate
+	err = json.Unmarshal(patched, &request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	request.Id = book.Id
+	request.Version = version
+
+	// Only the fields present in the patch are validated, so stored values
+	// that no longer satisfy the create rules do not block unrelated edits.
+	fields, err := helper.StructFieldNames(request, keys)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	err = s.Validate.StructPartial(request, fields...)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	return s.update(ctx, book, &request)
+}
+
+func (s *BookServiceImpl) update(ctx context.Context, book model.Book, request *web.BookUpdate) (*web.BookResponse, *response.CustomError) {
+	if request.Version != 0 && request.Version != book.Version {
+		return nil, response.PreconditionFailedError(repository.ErrVersionConflict.Error())
+	}
+
+	err := s.checkLinks(request.AuthorIds, request.PublisherIds, request.SubjectIds)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	if customErr := s.checkIsbn(request.Isbn, book.Id); customErr != nil {
+		return nil, customErr
+	}
+
+	before, err := s.snapshot(book)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
 	book.Title = request.Title
-	book.Author = request.Author
 	book.Isbn = request.Isbn
+	book.Category = bookCategory(request.Category)
 	book.PublicationYear = request.PublicationYear
 	book.Quantity = request.Quantity
+	book.ReplacementCostCents = request.ReplacementCostCents
 	book.UpdatedAt = time.Now()
 
-	err = s.BookRepository.Update(s.DB, &book)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.BookRepository.Update(tx, &book)
+		if err != nil {
+			return err
+		}
+		err = s.updateLinks(tx, book.Id, request.AuthorIds, request.PublisherIds, request.SubjectIds)
+		if err != nil {
+			return err
+		}
+		after := newBookSnapshot(book, request.AuthorIds, request.PublisherIds, request.SubjectIds)
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityBook, book.Id, before, after)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventBookUpdated, model.AuditEntityBook, book.Id, newBookEventData(after))
+	})
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return nil, response.PreconditionFailedError(err.Error())
+	}
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	BookResponse := web.BookResponse{
-		Title:           book.Title,
-		Author:          book.Author,
-		Isbn:            book.Isbn,
-		PublicationYear: book.PublicationYear,
-		Quantity:        book.Quantity,
+	bookResponses, err := s.toBookResponses([]model.Book{book})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
 	}
 
-	return &BookResponse, nil
+	return &bookResponses[0], nil
 }
 
 func (s *BookServiceImpl) Find(ctx context.Context, bookId int) (*web.BookResponse, *response.CustomError) {
@@ -111,16 +269,12 @@ func (s *BookServiceImpl) Find(ctx context.Context, bookId int) (*web.BookRespon
 		return nil, response.NotFoundError(err.Error())
 	}
 
-	BookResponse := web.BookResponse{
-		Id:              bookId,
-		Title:           book.Title,
-		Author:          book.Author,
-		Isbn:            book.Isbn,
-		PublicationYear: book.PublicationYear,
-		Quantity:        book.Quantity,
+	bookResponses, err := s.toBookResponses([]model.Book{book})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
 	}
 
-	return &BookResponse, nil
+	return &bookResponses[0], nil
 }
 
 func (s *BookServiceImpl) Delete(ctx context.Context, bookId int) *response.CustomError {
@@ -131,27 +285,447 @@ func (s *BookServiceImpl) Delete(ctx context.Context, bookId int) *response.Cust
 		return response.NotFoundError(err.Error())
 	}
 
-	s.BookRepository.Delete(s.DB, book.Id)
+	before, err := s.snapshot(book)
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	// Copies that may still come back must do so first, since loans of a
+	// deleted book can no longer be renewed. Lost copies do not hold the
+	// book up. Loans are counted after the delete has locked the book row,
+	// so a checkout that got in first is seen.
+	var onLoan bool
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.BookRepository.Delete(tx, book.Id)
+		if err != nil {
+			return err
+		}
+		var activeLoans int64
+		err = s.BorrowingRepository.CountActiveByBookId(tx, &activeLoans, book.Id)
+		if err != nil {
+			return err
+		}
+		if activeLoans > 0 {
+			onLoan = true
+			return errors.New("book has copies on loan")
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionDelete, model.AuditEntityBook, book.Id, before, nil)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventBookDeleted, model.AuditEntityBook, book.Id, newBookEventData(before))
+	})
+	if onLoan {
+		return response.ConflictError("Book has copies on loan and cannot be deleted until they are returned")
+	}
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
 
 	return nil
 }
 
-func (s *BookServiceImpl) FindAll(ctx context.Context) ([]web.BookResponse, *response.CustomError) {
+func (s *BookServiceImpl) FindAll(ctx context.Context, filter *web.BookFilter) ([]web.BookResponse, *response.CustomError) {
+	var books []model.Book
+	err := s.BookRepository.FindAll(s.DB, &books, &model.BookFilter{
+		AuthorId:    filter.AuthorId,
+		PublisherId: filter.PublisherId,
+		SubjectId:   filter.SubjectId,
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	bookResponses, err := s.toBookResponses(books)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return bookResponses, nil
+}
+
+func (s *BookServiceImpl) FindDeleted(ctx context.Context) ([]web.BookResponse, *response.CustomError) {
 	var books []model.Book
-	err := s.BookRepository.FindAll(s.DB, &books)
+	err := s.BookRepository.FindDeleted(s.DB, &books)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	bookResponses, err := s.toBookResponses(books)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return bookResponses, nil
+}
+
+// checkIsbn rejects an ISBN held by a book other than bookId. A deleted
+// book keeps its ISBN until it is purged, so the conflict points at
+// restoring it instead.
+func (s *BookServiceImpl) checkIsbn(isbn string, bookId int) *response.CustomError {
+	var books []model.Book
+	err := s.BookRepository.FindByIsbnWithDeleted(s.DB, &books, isbn)
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+	if len(books) == 0 || books[0].Id == bookId {
+		return nil
+	}
+	if books[0].DeletedAt != nil {
+		return response.ConflictError(fmt.Sprintf("ISBN belongs to deleted book %d; restore it with POST /api/admin/book/%d/restore", books[0].Id, books[0].Id))
+	}
+	return response.ConflictError(fmt.Sprintf("ISBN is already used by book %d", books[0].Id))
+}
+
+func (s *BookServiceImpl) Restore(ctx context.Context, bookId int) (*web.BookResponse, *response.CustomError) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.BookRepository.Restore(tx, bookId)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionRestore, model.AuditEntityBook, bookId, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		var book model.Book
+		err = s.BookRepository.Find(tx, &book, bookId)
+		if err != nil {
+			return err
+		}
+		after, err := s.snapshot(book)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventBookRestored, model.AuditEntityBook, bookId, newBookEventData(after))
+	})
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	return s.Find(ctx, bookId)
+}
+
+func (s *BookServiceImpl) Purge(ctx context.Context, deletedBefore time.Time) (int64, *response.CustomError) {
+	var purged int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = s.BookRepository.Purge(tx, deletedBefore)
+		if err != nil || purged == 0 {
+			return err
+		}
+		after := map[string]any{"Count": purged, "DeletedBefore": deletedBefore}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionPurge, model.AuditEntityBook, 0, nil, after)
+	})
+	if err != nil {
+		return 0, response.RepositoryError(err.Error())
+	}
+
+	return purged, nil
+}
+
+func (s *BookServiceImpl) UploadCover(ctx context.Context, bookId int, data []byte) (*web.BookResponse, *response.CustomError) {
+	if len(data) > MaxCoverSize {
+		return nil, response.PayloadTooLargeError(fmt.Sprintf("Cover must not exceed %d bytes", MaxCoverSize))
+	}
+
+	var book model.Book
+
+	err := s.BookRepository.Find(s.DB, &book, bookId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	// Trust the bytes, not the client supplied Content-Type.
+	contentType := http.DetectContentType(data)
+	if contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/gif" {
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, response.BadRequestError("Invalid image: " + err.Error())
+		}
+		if config.Width > MaxCoverDimension || config.Height > MaxCoverDimension {
+			return nil, response.PayloadTooLargeError(fmt.Sprintf("Cover must not exceed %dx%d pixels", MaxCoverDimension, MaxCoverDimension))
+		}
+	}
+	var src image.Image
+	switch contentType {
+	case "image/jpeg":
+		src, err = jpeg.Decode(bytes.NewReader(data))
+	case "image/png":
+		src, err = png.Decode(bytes.NewReader(data))
+	case "image/gif":
+		src, err = gif.Decode(bytes.NewReader(data))
+	default:
+		return nil, response.UnsupportedMediaTypeError("Cover must be a JPEG, PNG or GIF image, got " + contentType)
+	}
+	if err != nil {
+		return nil, response.BadRequestError("Invalid image: " + err.Error())
+	}
+
+	coverKey := fmt.Sprintf("covers/%d/%d", book.Id, time.Now().UnixNano())
+	err = s.Storage.Put(ctx, coverKey+"/"+CoverSizeOriginal, data)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+	for size, bound := range coverSizes {
+		var buf bytes.Buffer
+		thumbnail := helper.Thumbnail(src, bound.X, bound.Y)
+		if contentType == "image/jpeg" {
+			err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, thumbnail)
+		}
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+		err = s.Storage.Put(ctx, coverKey+"/"+size, buf.Bytes())
+		if err != nil {
+			return nil, response.GeneralError(err.Error())
+		}
+	}
+
+	before := book
+	oldCoverKey := book.CoverKey
+	book.CoverKey = coverKey
+	book.UpdatedAt = time.Now()
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.BookRepository.UpdateCover(tx, &book)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityBook, book.Id, &before, &book)
+		if err != nil {
+			return err
+		}
+		after, err := s.snapshot(book)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventBookUpdated, model.AuditEntityBook, book.Id, newBookEventData(after))
+	})
+	if err != nil {
+		s.deleteCover(ctx, coverKey)
+		return nil, response.RepositoryError(err.Error())
+	}
+	s.deleteCover(ctx, oldCoverKey)
+
+	bookResponses, err := s.toBookResponses([]model.Book{book})
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	var bookResponses []web.BookResponse
+	return &bookResponses[0], nil
+}
+
+func (s *BookServiceImpl) FindCover(ctx context.Context, bookId int, size string) ([]byte, string, *response.CustomError) {
+	if size == "" {
+		size = CoverSizeOriginal
+	}
+	if _, ok := coverSizes[size]; !ok && size != CoverSizeOriginal {
+		return nil, "", response.BadRequestError("Unknown cover size: " + size)
+	}
+
+	var book model.Book
+
+	err := s.BookRepository.Find(s.DB, &book, bookId)
+	if err != nil {
+		return nil, "", response.NotFoundError(err.Error())
+	}
+	if book.CoverKey == "" {
+		return nil, "", response.NotFoundError("Book has no cover")
+	}
+
+	data, err := s.Storage.Get(ctx, book.CoverKey+"/"+size)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", response.NotFoundError(err.Error())
+	}
+	if err != nil {
+		return nil, "", response.GeneralError(err.Error())
+	}
+
+	return data, http.DetectContentType(data), nil
+}
+
+func (s *BookServiceImpl) deleteCover(ctx context.Context, coverKey string) {
+	if coverKey == "" {
+		return
+	}
+	s.Storage.Delete(ctx, coverKey+"/"+CoverSizeOriginal)
+	for size := range coverSizes {
+		s.Storage.Delete(ctx, coverKey+"/"+size)
+	}
+}
+
+func bookCategory(category string) string {
+	if category == "" {
+		return model.BookCategoryGeneral
+	}
+	return category
+}
+
+type bookSnapshot struct {
+	model.Book
+	AuthorIds    []int
+	PublisherIds []int
+	SubjectIds   []int
+}
+
+func newBookSnapshot(book model.Book, authorIds, publisherIds, subjectIds []int) *bookSnapshot {
+	return &bookSnapshot{
+		Book:         book,
+		AuthorIds:    uniqueIds(authorIds),
+		PublisherIds: uniqueIds(publisherIds),
+		SubjectIds:   uniqueIds(subjectIds),
+	}
+}
+
+func newBookEventData(snapshot *bookSnapshot) web.BookEventData {
+	return web.BookEventData{
+		Id:                   snapshot.Id,
+		Title:                snapshot.Title,
+		Isbn:                 snapshot.Isbn,
+		Category:             snapshot.Category,
+		PublicationYear:      snapshot.PublicationYear,
+		Quantity:             snapshot.Quantity,
+		ReplacementCostCents: snapshot.ReplacementCostCents,
+		Version:              snapshot.Version,
+		AuthorIds:            snapshot.AuthorIds,
+		PublisherIds:         snapshot.PublisherIds,
+		SubjectIds:           snapshot.SubjectIds,
+	}
+}
+
+// snapshot captures a book together with its current links for auditing.
+func (s *BookServiceImpl) snapshot(book model.Book) (*bookSnapshot, error) {
+	bookResponses, err := s.toBookResponses([]model.Book{book})
+	if err != nil {
+		return nil, err
+	}
+
+	var authorIds, publisherIds, subjectIds []int
+	for _, author := range bookResponses[0].Authors {
+		authorIds = append(authorIds, author.Id)
+	}
+	for _, publisher := range bookResponses[0].Publishers {
+		publisherIds = append(publisherIds, publisher.Id)
+	}
+	for _, subject := range bookResponses[0].Subjects {
+		subjectIds = append(subjectIds, subject.Id)
+	}
+
+	return newBookSnapshot(book, authorIds, publisherIds, subjectIds), nil
+}
+
+func (s *BookServiceImpl) checkLinks(authorIds, publisherIds, subjectIds []int) error {
+	var authors []model.Author
+	err := s.AuthorRepository.FindByIds(s.DB, &authors, authorIds)
+	if err != nil {
+		return err
+	}
+	if len(authors) != countUnique(authorIds) {
+		return errors.New("author not found")
+	}
+
+	var publishers []model.Publisher
+	err = s.PublisherRepository.FindByIds(s.DB, &publishers, publisherIds)
+	if err != nil {
+		return err
+	}
+	if len(publishers) != countUnique(publisherIds) {
+		return errors.New("publisher not found")
+	}
+
+	var subjects []model.Subject
+	err = s.SubjectRepository.FindByIds(s.DB, &subjects, subjectIds)
+	if err != nil {
+		return err
+	}
+	if len(subjects) != countUnique(subjectIds) {
+		return errors.New("subject not found")
+	}
+
+	return nil
+}
+
+func (s *BookServiceImpl) updateLinks(tx *gorm.DB, bookId int, authorIds, publisherIds, subjectIds []int) error {
+	err := s.BookRepository.UpdateAuthors(tx, bookId, uniqueIds(authorIds))
+	if err != nil {
+		return err
+	}
+	err = s.BookRepository.UpdatePublishers(tx, bookId, uniqueIds(publisherIds))
+	if err != nil {
+		return err
+	}
+	return s.BookRepository.UpdateSubjects(tx, bookId, uniqueIds(subjectIds))
+}
+
+// toBookResponses loads the authors, publishers and subjects of all books in
+// one query per relation instead of one per book.
+func (s *BookServiceImpl) toBookResponses(books []model.Book) ([]web.BookResponse, error) {
+	bookIds := make([]int, 0, len(books))
+	for _, book := range books {
+		bookIds = append(bookIds, book.Id)
+	}
+
+	var bookAuthors []model.BookAuthor
+	err := s.AuthorRepository.FindByBookIds(s.DB, &bookAuthors, bookIds)
+	if err != nil {
+		return nil, err
+	}
+	authors := map[int][]web.AuthorResponse{}
+	for _, bookAuthor := range bookAuthors {
+		authors[bookAuthor.BookId] = append(authors[bookAuthor.BookId], web.AuthorResponse{
+			Id:   bookAuthor.Id,
+			Name: bookAuthor.Name,
+			Bio:  bookAuthor.Bio,
+		})
+	}
+
+	var bookPublishers []model.BookPublisher
+	err = s.PublisherRepository.FindByBookIds(s.DB, &bookPublishers, bookIds)
+	if err != nil {
+		return nil, err
+	}
+	publishers := map[int][]web.PublisherResponse{}
+	for _, bookPublisher := range bookPublishers {
+		publishers[bookPublisher.BookId] = append(publishers[bookPublisher.BookId], web.PublisherResponse{
+			Id:   bookPublisher.Id,
+			Name: bookPublisher.Name,
+		})
+	}
+
+	var bookSubjects []model.BookSubject
+	err = s.SubjectRepository.FindByBookIds(s.DB, &bookSubjects, bookIds)
+	if err != nil {
+		return nil, err
+	}
+	subjects := map[int][]web.SubjectResponse{}
+	for _, bookSubject := range bookSubjects {
+		subjects[bookSubject.BookId] = append(subjects[bookSubject.BookId], web.SubjectResponse{
+			Id:   bookSubject.Id,
+			Name: bookSubject.Name,
+		})
+	}
+
+	bookResponses := []web.BookResponse{}
 	for _, book := range books {
 		bookResponse := web.BookResponse{
-			Id:              book.Id,
-			Title:           book.Title,
-			Author:          book.Author,
-			Isbn:            book.Isbn,
-			PublicationYear: book.PublicationYear,
-			Quantity:        book.Quantity,
+			Id:                   book.Id,
+			Title:                book.Title,
+			Authors:              authors[book.Id],
+			Publishers:           publishers[book.Id],
+			Subjects:             subjects[book.Id],
+			Isbn:                 book.Isbn,
+			Category:             book.Category,
+			PublicationYear:      book.PublicationYear,
+			Quantity:             book.Quantity,
+			ReplacementCostCents: book.ReplacementCostCents,
+			Version:              book.Version,
+			DeletedAt:            book.DeletedAt,
+		}
+		if book.CoverKey != "" {
+			bookResponse.CoverUrl = fmt.Sprintf("/api/book/%d/cover", book.Id)
 		}
 		bookResponses = append(bookResponses, bookResponse)
 	}
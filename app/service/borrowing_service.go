@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"kukuh/go-gin-library-project/app/model"
 	"kukuh/go-gin-library-project/app/repository"
 	"kukuh/go-gin-library-project/app/web"
 	"kukuh/go-gin-library-project/response"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -15,23 +17,38 @@ import (
 type BorrowingService interface {
 	Create(ctx context.Context, request *web.BorrowingCreateRequest) (*web.BorrowingResponse, *response.CustomError)
 	Return(ctx context.Context, borrowingId int, userId int) (*web.BorrowingResponse, *response.CustomError)
-	Find(ctx context.Context, borrowingId int) (*web.BorrowingResponse, *response.CustomError)
-	FindAll(ctx context.Context) ([]web.BorrowingResponse, *response.CustomError)
+	UpdateStatus(ctx context.Context, borrowingId int, request *web.BorrowingStatusRequest) (*web.BorrowingResponse, *response.CustomError)
+	Renew(ctx context.Context, borrowingId int, userId int) (*web.BorrowingResponse, *response.CustomError)
+	Find(ctx context.Context, borrowingId int, userId int, role string, expand string) (*web.BorrowingResponse, *response.CustomError)
+	FindAll(ctx context.Context, expand string) ([]web.BorrowingResponse, *response.CustomError)
+	FindByUser(ctx context.Context, userId int, filter *web.BorrowingFilter) (*web.PageResponse, *response.CustomError)
+	FindLost(ctx context.Context, filter *web.LostItemFilter) (*web.PageResponse, *response.CustomError)
+	MarkOverdue(ctx context.Context, now time.Time) (int64, *response.CustomError)
 }
 
 type BorrowingServiceImpl struct {
-	BorrowingRepository repository.BorrowingRepository
-	BookRepository      repository.BookRepository
-	DB                  *gorm.DB
-	Validate            *validator.Validate
+	BorrowingRepository      repository.BorrowingRepository
+	BookRepository           repository.BookRepository
+	UserRepository           repository.UserRepository
+	FineRepository           repository.FineRepository
+	AuditLogRepository       repository.AuditLogRepository
+	OutboxRepository         repository.OutboxRepository
+	CirculationPolicyService CirculationPolicyService
+	DB                       *gorm.DB
+	Validate                 *validator.Validate
 }
 
-func NewBorrowingService(borrowingRepository repository.BorrowingRepository, bookRepository repository.BookRepository, DB *gorm.DB, validate *validator.Validate) BorrowingService {
+func NewBorrowingService(borrowingRepository repository.BorrowingRepository, bookRepository repository.BookRepository, userRepository repository.UserRepository, fineRepository repository.FineRepository, auditLogRepository repository.AuditLogRepository, outboxRepository repository.OutboxRepository, circulationPolicyService CirculationPolicyService, DB *gorm.DB, validate *validator.Validate) BorrowingService {
 	return &BorrowingServiceImpl{
-		BorrowingRepository: borrowingRepository,
-		BookRepository:      bookRepository,
-		DB:                  DB,
-		Validate:            validate,
+		BorrowingRepository:      borrowingRepository,
+		BookRepository:           bookRepository,
+		UserRepository:           userRepository,
+		FineRepository:           fineRepository,
+		AuditLogRepository:       auditLogRepository,
+		OutboxRepository:         outboxRepository,
+		CirculationPolicyService: circulationPolicyService,
+		DB:                       DB,
+		Validate:                 validate,
 	}
 }
 
@@ -51,45 +68,117 @@ func (s *BorrowingServiceImpl) Create(ctx context.Context, request *web.Borrowin
 		return nil, response.BadRequestError("Out of stock!")
 	}
 
-	var t time.Time
-	dueDate, err := time.Parse("2006-01-02", request.DueDate)
-	if err != nil {
-		return nil, response.GeneralError(err.Error())
-	}
-
 	borrowing := model.Borrowing{
 		BookId:     request.BookId,
 		UserId:     request.UserId,
 		BorrowDate: time.Now(),
-		DueDate:    dueDate,
-		Status:     "borrowed",
-		ReturnDate: t,
+		Status:     model.BorrowingStatusBorrowed,
 	}
 
-	err = s.BorrowingRepository.Save(s.DB, &borrowing)
+	userMissing := false
+	var policyErr *response.CustomError
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the patron serialises their checkouts, so the loan limit
+		// is checked against loans that cannot change underneath it.
+		var user model.User
+		err := s.UserRepository.FindByIdForUpdate(tx, &user, request.UserId)
+		if err != nil {
+			userMissing = true
+			return err
+		}
+		borrowing.DueDate, policyErr = s.CirculationPolicyService.EvaluateCheckout(ctx, tx, user, book, request.DueDate)
+		if policyErr != nil {
+			return errors.New("checkout refused by circulation policy")
+		}
+
+		err = s.BookRepository.TakeCopy(tx, &book)
+		if err != nil {
+			return err
+		}
+		bookBefore := book
+		bookBefore.Quantity++
+		bookBefore.Version--
+		err = s.BorrowingRepository.Save(tx, &borrowing)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionBorrow, model.AuditEntityBorrowing, borrowing.Id, nil, &borrowing)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityBook, book.Id, &bookBefore, &book)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventBorrowingCreated, model.AuditEntityBorrowing, borrowing.Id, toBorrowingResponse(borrowing))
+	})
+	if policyErr != nil {
+		return nil, policyErr
+	}
+	if userMissing {
+		return nil, response.NotFoundError(err.Error())
+	}
+	if errors.Is(err, repository.ErrOutOfStock) {
+		return nil, response.BadRequestError("Out of stock!")
+	}
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	book.Quantity--
-	err = s.BookRepository.UpdateQuantity(s.DB, &book)
+	borrowingResponse := toBorrowingResponse(borrowing)
+	return &borrowingResponse, nil
+}
+
+func (s *BorrowingServiceImpl) Return(ctx context.Context, borrowingId int, userId int) (*web.BorrowingResponse, *response.CustomError) {
+	var borrowing model.Borrowing
+
+	err := s.BorrowingRepository.Find(s.DB, &borrowing, borrowingId)
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	BorrowingResponse := web.BorrowingResponse{
-		Id:         borrowing.Id,
-		BookId:     request.BookId,
-		UserId:     request.UserId,
-		BorrowDate: borrowing.BorrowDate,
-		DueDate:    borrowing.DueDate,
-		Status:     borrowing.Status,
+	if userId != borrowing.UserId {
+		return nil, response.BadRequestError("User not match!")
+	}
+	if borrowing.Status.IsReturned() {
+		return nil, response.BadRequestError("Borrowing already returned!")
+	}
+	// Lost loans and claims of an earlier return are settled by staff, who
+	// check the copy in through UpdateStatus.
+	if !borrowing.Status.IsOpen() {
+		return nil, response.BadRequestError("Borrowing is " + string(borrowing.Status) + ", please return it at the desk!")
 	}
 
-	return &BorrowingResponse, nil
+	return s.transition(ctx, borrowing, returnStatus(borrowing, time.Now()))
 }
 
-func (s *BorrowingServiceImpl) Return(ctx context.Context, borrowingId int, userId int) (*web.BorrowingResponse, *response.CustomError) {
+// UpdateStatus lets staff declare a loan lost or claimed returned, check in a
+// damaged copy, and check in copies that turn up after being lost or claimed
+// returned.
+func (s *BorrowingServiceImpl) UpdateStatus(ctx context.Context, borrowingId int, request *web.BorrowingStatusRequest) (*web.BorrowingResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var borrowing model.Borrowing
+	err = s.BorrowingRepository.Find(s.DB, &borrowing, borrowingId)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+	if borrowing.Id == 0 {
+		return nil, response.NotFoundError("Borrowing not found!")
+	}
+
+	next := model.BorrowingStatus(request.Status)
+	if next == model.BorrowingStatusReturned {
+		next = returnStatus(borrowing, time.Now())
+	}
+
+	return s.transition(ctx, borrowing, next)
+}
+
+func (s *BorrowingServiceImpl) Renew(ctx context.Context, borrowingId int, userId int) (*web.BorrowingResponse, *response.CustomError) {
 	var borrowing model.Borrowing
 
 	err := s.BorrowingRepository.Find(s.DB, &borrowing, borrowingId)
@@ -100,6 +189,18 @@ func (s *BorrowingServiceImpl) Return(ctx context.Context, borrowingId int, user
 	if userId != borrowing.UserId {
 		return nil, response.BadRequestError("User not match!")
 	}
+	if borrowing.Status.IsReturned() {
+		return nil, response.BadRequestError("Borrowing already returned!")
+	}
+	if !borrowing.Status.IsOpen() {
+		return nil, response.BadRequestError("Borrowing is " + string(borrowing.Status) + " and cannot be renewed!")
+	}
+
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, borrowing.UserId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
 
 	var book model.Book
 	err = s.BookRepository.Find(s.DB, &book, borrowing.BookId)
@@ -107,79 +208,379 @@ func (s *BorrowingServiceImpl) Return(ctx context.Context, borrowingId int, user
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	status := "returned"
-	if time.Now().After(borrowing.DueDate) {
-		status = "late_returned"
+	dueDate, customErr := s.CirculationPolicyService.EvaluateRenewal(ctx, user, book, borrowing)
+	if customErr != nil {
+		return nil, customErr
 	}
 
-	borrowing.Status = status
-	borrowing.ReturnDate = time.Now()
+	borrowingBefore := borrowing
+	borrowing.DueDate = dueDate
+	borrowing.RenewalCount++
+	if borrowing.Status == model.BorrowingStatusOverdue && dueDate.After(time.Now()) {
+		borrowing.Status = model.BorrowingStatusBorrowed
+	}
 
-	err = s.BorrowingRepository.UpdateStatus(s.DB, &borrowing)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.BorrowingRepository.UpdateDueDate(tx, &borrowing)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionRenew, model.AuditEntityBorrowing, borrowing.Id, &borrowingBefore, &borrowing)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventBorrowingRenewed, model.AuditEntityBorrowing, borrowing.Id, toBorrowingResponse(borrowing))
+	})
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	book.Quantity++
-	err = s.BookRepository.UpdateQuantity(s.DB, &book)
+	borrowingResponse := toBorrowingResponse(borrowing)
+	return &borrowingResponse, nil
+}
+
+// Find returns a borrowing to staff or to the patron who owns it. Anyone else
+// gets the same error as for a missing borrowing, so ids cannot be probed.
+func (s *BorrowingServiceImpl) Find(ctx context.Context, borrowingId int, userId int, role string, expand string) (*web.BorrowingResponse, *response.CustomError) {
+	expansion, customErr := parseBorrowingExpand(expand)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	var borrowing model.BorrowingJoin
+	err := s.BorrowingRepository.FindJoin(s.DB, &borrowing, borrowingId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+	if borrowing.Id == 0 || (borrowing.UserId != userId && role != model.RoleAdmin && role != model.RoleLibrarian) {
+		return nil, response.NotFoundError("Borrowing not found!")
+	}
+
+	borrowingResponse := toBorrowingJoinResponse(borrowing, expansion)
+	return &borrowingResponse, nil
+}
+
+func (s *BorrowingServiceImpl) FindAll(ctx context.Context, expand string) ([]web.BorrowingResponse, *response.CustomError) {
+	expansion, customErr := parseBorrowingExpand(expand)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	var borrowings []model.BorrowingJoin
+	err := s.BorrowingRepository.FindAll(s.DB, &borrowings)
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	BorrowingResponse := web.BorrowingResponse{
-		Id:         borrowing.Id,
-		BookId:     borrowing.BookId,
-		UserId:     borrowing.UserId,
-		BorrowDate: borrowing.BorrowDate,
-		DueDate:    borrowing.DueDate,
-		Status:     borrowing.Status,
-		ReturnDate: borrowing.ReturnDate,
+	var borrowingResponses []web.BorrowingResponse
+	for _, borrowing := range borrowings {
+		borrowingResponses = append(borrowingResponses, toBorrowingJoinResponse(borrowing, expansion))
 	}
 
-	return &BorrowingResponse, nil
+	return borrowingResponses, nil
 }
 
-func (s *BorrowingServiceImpl) Find(ctx context.Context, borrowingId int) (*web.BorrowingResponse, *response.CustomError) {
-	var borrowing model.Borrowing
+func (s *BorrowingServiceImpl) FindByUser(ctx context.Context, userId int, filter *web.BorrowingFilter) (*web.PageResponse, *response.CustomError) {
+	err := s.Validate.Struct(filter)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
 
-	err := s.BorrowingRepository.Find(s.DB, &borrowing, borrowingId)
+	expansion, customErr := parseBorrowingExpand(filter.Expand)
+	if customErr != nil {
+		return nil, customErr
+	}
+
+	page := filter.PageRequest.Normalize()
+
+	var borrowings []model.BorrowingJoin
+	var total int64
+	err = s.BorrowingRepository.FindByUserId(s.DB, &borrowings, &total, &model.BorrowingFilter{
+		UserId: userId,
+		Status: model.BorrowingStatus(filter.Status),
+		Active: filter.Active,
+		Limit:  page.Limit,
+		Offset: page.Offset(),
+	})
 	if err != nil {
-		return nil, response.NotFoundError(err.Error())
+		return nil, response.RepositoryError(err.Error())
 	}
 
-	BorrowingResponse := web.BorrowingResponse{
-		Id:         borrowing.Id,
-		BookId:     borrowing.BookId,
-		UserId:     borrowing.UserId,
-		BorrowDate: borrowing.BorrowDate,
-		DueDate:    borrowing.DueDate,
-		Status:     borrowing.Status,
-		ReturnDate: borrowing.ReturnDate,
+	borrowingResponses := []web.BorrowingResponse{}
+	for _, borrowing := range borrowings {
+		borrowingResponses = append(borrowingResponses, toBorrowingJoinResponse(borrowing, expansion))
 	}
 
-	return &BorrowingResponse, nil
+	return &web.PageResponse{
+		Items: borrowingResponses,
+		Page:  page.Page,
+		Limit: page.Limit,
+		Total: total,
+	}, nil
 }
 
-func (s *BorrowingServiceImpl) FindAll(ctx context.Context) ([]web.BorrowingResponse, *response.CustomError) {
-	var borrowings []model.Borrowing
-	err := s.BorrowingRepository.FindAll(s.DB, &borrowings)
+func (s *BorrowingServiceImpl) FindLost(ctx context.Context, filter *web.LostItemFilter) (*web.PageResponse, *response.CustomError) {
+	err := s.Validate.Struct(filter)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	page := filter.PageRequest.Normalize()
+
+	var items []model.LostItem
+	var total int64
+	err = s.BorrowingRepository.FindLost(s.DB, &items, &total, &model.LostItemFilter{
+		Status: model.BorrowingStatus(filter.Status),
+		Limit:  page.Limit,
+		Offset: page.Offset(),
+	})
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	var borrowingResponses []web.BorrowingResponse
-	for _, borrowing := range borrowings {
-		borrowingResponse := web.BorrowingResponse{
-			Id:         borrowing.Id,
-			BookId:     borrowing.BookId,
-			UserId:     borrowing.UserId,
-			BorrowDate: borrowing.BorrowDate,
-			DueDate:    borrowing.DueDate,
-			Status:     borrowing.Status,
-			ReturnDate: borrowing.ReturnDate,
+	itemResponses := []web.LostItemResponse{}
+	for _, item := range items {
+		itemResponses = append(itemResponses, web.LostItemResponse{
+			BorrowingId:      item.BorrowingId,
+			Status:           string(item.Status),
+			BookId:           item.BookId,
+			BookTitle:        item.BookTitle,
+			BookIsbn:         item.BookIsbn,
+			UserId:           item.UserId,
+			UserName:         item.UserName,
+			DueDate:          item.DueDate,
+			ReturnDate:       item.ReturnDate,
+			ChargedCents:     item.ChargedCents,
+			OutstandingCents: item.OutstandingCents,
+		})
+	}
+
+	return &web.PageResponse{
+		Items: itemResponses,
+		Page:  page.Page,
+		Limit: page.Limit,
+		Total: total,
+	}, nil
+}
+
+// MarkOverdue moves open loans whose due date has passed to the overdue
+// status, recording an audit entry for each.
+func (s *BorrowingServiceImpl) MarkOverdue(ctx context.Context, now time.Time) (int64, *response.CustomError) {
+	var marked int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var borrowings []model.Borrowing
+		err := s.BorrowingRepository.FindOverdue(tx, &borrowings, now)
+		if err != nil || len(borrowings) == 0 {
+			return err
 		}
-		borrowingResponses = append(borrowingResponses, borrowingResponse)
+
+		marked, err = s.BorrowingRepository.MarkOverdue(tx, now)
+		if err != nil {
+			return err
+		}
+
+		for _, borrowing := range borrowings {
+			borrowingBefore := borrowing
+			borrowing.Status = model.BorrowingStatusOverdue
+			err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityBorrowing, borrowing.Id, &borrowingBefore, &borrowing)
+			if err != nil {
+				return err
+			}
+			err = recordEvent(ctx, tx, s.OutboxRepository, model.EventBorrowingOverdue, model.AuditEntityBorrowing, borrowing.Id, toBorrowingResponse(borrowing))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, response.RepositoryError(err.Error())
 	}
 
-	return borrowingResponses, nil
+	return marked, nil
+}
+
+func toBorrowingResponse(borrowing model.Borrowing) web.BorrowingResponse {
+	return web.BorrowingResponse{
+		Id:           borrowing.Id,
+		BookId:       borrowing.BookId,
+		UserId:       borrowing.UserId,
+		BorrowDate:   borrowing.BorrowDate,
+		DueDate:      borrowing.DueDate,
+		Status:       string(borrowing.Status),
+		ReturnDate:   borrowing.ReturnDate,
+		RenewalCount: borrowing.RenewalCount,
+	}
+}
+
+// transition moves borrowing to next. Moving to a returned status stamps the
+// return date and puts the copy back in stock unless it came back damaged.
+// Lost and damaged copies are billed at the book's replacement cost, and a
+// lost copy that turns up has that charge reversed. The update only applies
+// if the borrowing is still in the status it was read in, so a loan cannot be
+// returned, restocked or billed twice.
+func (s *BorrowingServiceImpl) transition(ctx context.Context, borrowing model.Borrowing, next model.BorrowingStatus) (*web.BorrowingResponse, *response.CustomError) {
+	if borrowing.Status.IsReturned() {
+		return nil, response.BadRequestError("Borrowing already returned!")
+	}
+	if !borrowing.Status.CanTransitionTo(next) {
+		return nil, response.BadRequestError("Cannot change borrowing from " + string(borrowing.Status) + " to " + string(next) + "!")
+	}
+
+	borrowingBefore := borrowing
+	borrowing.Status = next
+	action := model.AuditActionUpdate
+	eventType := model.EventBorrowingStatusChanged
+	if next.IsReturned() {
+		now := time.Now()
+		borrowing.ReturnDate = &now
+		action = model.AuditActionReturn
+		eventType = model.EventBorrowingReturned
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.BorrowingRepository.UpdateStatus(tx, &borrowing, borrowingBefore.Status)
+		if err != nil {
+			return err
+		}
+		// The book may have been retired while the copy was lost.
+		var book model.Book
+		err = s.BookRepository.FindWithDeleted(tx, &book, borrowing.BookId)
+		if err != nil {
+			return err
+		}
+		if next.IsReturned() && next != model.BorrowingStatusDamaged {
+			err = s.BookRepository.PutBackCopy(tx, &book)
+			if err != nil {
+				return err
+			}
+			bookBefore := book
+			bookBefore.Quantity--
+			bookBefore.Version--
+			err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityBook, book.Id, &bookBefore, &book)
+			if err != nil {
+				return err
+			}
+		}
+		if borrowingBefore.Status == model.BorrowingStatusLost {
+			err = s.reverseLostCharge(tx, borrowing)
+			if err != nil {
+				return err
+			}
+		}
+		if next.ChargesReplacement() {
+			err = s.chargeReplacement(tx, borrowing, book)
+			if err != nil {
+				return err
+			}
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, action, model.AuditEntityBorrowing, borrowing.Id, &borrowingBefore, &borrowing)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, eventType, model.AuditEntityBorrowing, borrowing.Id, toBorrowingResponse(borrowing))
+	})
+	if errors.Is(err, repository.ErrBorrowingStatusChanged) {
+		return nil, response.ConflictError("Borrowing was changed by another request, please try again!")
+	}
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	borrowingResponse := toBorrowingResponse(borrowing)
+	return &borrowingResponse, nil
+}
+
+func (s *BorrowingServiceImpl) chargeReplacement(tx *gorm.DB, borrowing model.Borrowing, book model.Book) error {
+	if book.ReplacementCostCents <= 0 {
+		return nil
+	}
+	reason := model.FineReasonLost
+	if borrowing.Status == model.BorrowingStatusDamaged {
+		reason = model.FineReasonDamaged
+	}
+	fine := model.Fine{
+		UserId:      borrowing.UserId,
+		BorrowingId: &borrowing.Id,
+		AmountCents: book.ReplacementCostCents,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+	return s.FineRepository.Save(tx, &fine)
+}
+
+// reverseLostCharge waives the unpaid lost item charge of a loan whose copy
+// was found, or credits it back to the patron if it was already paid.
+func (s *BorrowingServiceImpl) reverseLostCharge(tx *gorm.DB, borrowing model.Borrowing) error {
+	var fines []model.Fine
+	err := s.FineRepository.FindByBorrowingId(tx, &fines, borrowing.Id, model.FineReasonLost)
+	if err != nil {
+		return err
+	}
+	for _, fine := range fines {
+		if fine.PaidAt == nil {
+			err = s.FineRepository.Waive(tx, fine.Id, time.Now())
+		} else {
+			err = s.FineRepository.Save(tx, &model.Fine{
+				UserId:      fine.UserId,
+				BorrowingId: &borrowing.Id,
+				AmountCents: -fine.AmountCents,
+				Reason:      model.FineReasonLostFound,
+				CreatedAt:   time.Now(),
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returnStatus is the status a loan checked in at now ends up in.
+func returnStatus(borrowing model.Borrowing, now time.Time) model.BorrowingStatus {
+	if now.After(borrowing.DueDate) {
+		return model.BorrowingStatusLateReturned
+	}
+	return model.BorrowingStatusReturned
+}
+
+// borrowingExpand lists the related records embedded in a borrowing
+// response, as requested with ?expand=book,user.
+type borrowingExpand struct {
+	book bool
+	user bool
+}
+
+func parseBorrowingExpand(expand string) (borrowingExpand, *response.CustomError) {
+	var expansion borrowingExpand
+	for _, field := range strings.Split(expand, ",") {
+		switch strings.TrimSpace(field) {
+		case "":
+		case "book":
+			expansion.book = true
+		case "user":
+			expansion.user = true
+		default:
+			return expansion, response.BadRequestError("Invalid expand value: " + field)
+		}
+	}
+	return expansion, nil
+}
+
+func toBorrowingJoinResponse(borrowing model.BorrowingJoin, expansion borrowingExpand) web.BorrowingResponse {
+	borrowingResponse := toBorrowingResponse(borrowing.Borrowing)
+	if expansion.book {
+		borrowingResponse.Book = &web.BorrowingBookResponse{
+			Id:    borrowing.BookId,
+			Title: borrowing.BookTitle,
+			Isbn:  borrowing.BookIsbn,
+		}
+	}
+	if expansion.user {
+		borrowingResponse.User = &web.BorrowingUserResponse{
+			Id:   borrowing.UserId,
+			Name: borrowing.UserName,
+		}
+	}
+	return borrowingResponse
 }
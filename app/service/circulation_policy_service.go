@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	ViolationNoPolicy         = "NO_POLICY"
+	ViolationAccountInactive  = "ACCOUNT_INACTIVE"
+	ViolationLoanNotAllowed   = "LOAN_NOT_ALLOWED"
+	ViolationMaxLoansReached  = "MAX_LOANS_REACHED"
+	ViolationMaxRenewals      = "MAX_RENEWALS_REACHED"
+	ViolationOverdueItems     = "HAS_OVERDUE_ITEMS"
+	ViolationOutstandingFines = "OUTSTANDING_FINES"
+	ViolationDueDateInPast    = "DUE_DATE_IN_PAST"
+	ViolationDueDateTooFar    = "DUE_DATE_TOO_FAR"
+	ViolationInvalidDueDate   = "INVALID_DUE_DATE"
+	dueDateLayout             = "2006-01-02"
+)
+
+// CirculationPolicyService is the single place where loan rules are
+// evaluated. Every check runs, so a rejection lists all the reasons at once
+// instead of making the patron fix them one by one.
+type CirculationPolicyService interface {
+	Save(ctx context.Context, request *web.CirculationPolicyRequest) (*web.CirculationPolicyResponse, *response.CustomError)
+	Delete(ctx context.Context, policyId int) *response.CustomError
+	FindAll(ctx context.Context) ([]web.CirculationPolicyResponse, *response.CustomError)
+	EvaluateCheckout(ctx context.Context, tx *gorm.DB, user model.User, book model.Book, requestedDueDate string) (time.Time, *response.CustomError)
+	EvaluateRenewal(ctx context.Context, user model.User, book model.Book, borrowing model.Borrowing) (time.Time, *response.CustomError)
+}
+
+type CirculationPolicyServiceImpl struct {
+	CirculationPolicyRepository repository.CirculationPolicyRepository
+	BorrowingRepository         repository.BorrowingRepository
+	FineRepository              repository.FineRepository
+	DB                          *gorm.DB
+	Validate                    *validator.Validate
+}
+
+func NewCirculationPolicyService(circulationPolicyRepository repository.CirculationPolicyRepository, borrowingRepository repository.BorrowingRepository, fineRepository repository.FineRepository, DB *gorm.DB, validate *validator.Validate) CirculationPolicyService {
+	return &CirculationPolicyServiceImpl{
+		CirculationPolicyRepository: circulationPolicyRepository,
+		BorrowingRepository:         borrowingRepository,
+		FineRepository:              fineRepository,
+		DB:                          DB,
+		Validate:                    validate,
+	}
+}
+
+func (s *CirculationPolicyServiceImpl) Save(ctx context.Context, request *web.CirculationPolicyRequest) (*web.CirculationPolicyResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	policy := model.CirculationPolicy{
+		PatronType:     request.PatronType,
+		BookCategory:   request.BookCategory,
+		LoanDays:       request.LoanDays,
+		MaxLoanDays:    request.MaxLoanDays,
+		MaxLoans:       request.MaxLoans,
+		MaxRenewals:    request.MaxRenewals,
+		MaxFineCents:   request.MaxFineCents,
+		BlockOnOverdue: request.BlockOnOverdue,
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.CirculationPolicyRepository.Save(tx, &policy)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	policyResponse := toCirculationPolicyResponse(policy)
+	return &policyResponse, nil
+}
+
+func (s *CirculationPolicyServiceImpl) Delete(ctx context.Context, policyId int) *response.CustomError {
+	err := s.CirculationPolicyRepository.Delete(s.DB, policyId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+
+	return nil
+}
+
+func (s *CirculationPolicyServiceImpl) FindAll(ctx context.Context) ([]web.CirculationPolicyResponse, *response.CustomError) {
+	var policies []model.CirculationPolicy
+	err := s.CirculationPolicyRepository.FindAll(s.DB, &policies)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	policyResponses := []web.CirculationPolicyResponse{}
+	for _, policy := range policies {
+		policyResponses = append(policyResponses, toCirculationPolicyResponse(policy))
+	}
+
+	return policyResponses, nil
+}
+
+// EvaluateCheckout runs in the checkout transaction tx, which should hold
+// the patron's row locked so concurrent checkouts cannot both pass the loan
+// limit.
+func (s *CirculationPolicyServiceImpl) EvaluateCheckout(ctx context.Context, tx *gorm.DB, user model.User, book model.Book, requestedDueDate string) (time.Time, *response.CustomError) {
+	policy, customErr := s.findPolicy(tx, user, book)
+	if customErr != nil {
+		return time.Time{}, customErr
+	}
+
+	violations, err := s.checkPatron(tx, user, policy, 0)
+	if err != nil {
+		return time.Time{}, response.RepositoryError(err.Error())
+	}
+
+	var activeLoans int64
+	err = s.BorrowingRepository.CountActiveByUserId(tx, &activeLoans, user.Id)
+	if err != nil {
+		return time.Time{}, response.RepositoryError(err.Error())
+	}
+	if policy.MaxLoans == 0 {
+		violations = append(violations, web.PolicyViolation{
+			Code:    ViolationLoanNotAllowed,
+			Message: fmt.Sprintf("%s patrons may not borrow %s books", user.PatronType, book.Category),
+		})
+	} else if activeLoans >= int64(policy.MaxLoans) {
+		violations = append(violations, web.PolicyViolation{
+			Code:    ViolationMaxLoansReached,
+			Message: fmt.Sprintf("You already have %d of %d allowed loans", activeLoans, policy.MaxLoans),
+		})
+	}
+
+	now := time.Now()
+	dueDate := endOfDay(now.AddDate(0, 0, policy.LoanDays))
+	if requestedDueDate != "" {
+		parsed, err := time.ParseInLocation(dueDateLayout, requestedDueDate, time.Local)
+		if err != nil {
+			violations = append(violations, web.PolicyViolation{
+				Code:    ViolationInvalidDueDate,
+				Message: "due_date must be formatted as " + dueDateLayout,
+			})
+		} else {
+			dueDate = endOfDay(parsed)
+			if !dueDate.After(now) {
+				violations = append(violations, web.PolicyViolation{
+					Code:    ViolationDueDateInPast,
+					Message: "due_date must be in the future",
+				})
+			} else if dueDate.After(endOfDay(now.AddDate(0, 0, policy.MaxLoanDays))) {
+				violations = append(violations, web.PolicyViolation{
+					Code:    ViolationDueDateTooFar,
+					Message: fmt.Sprintf("Loans may not exceed %d days", policy.MaxLoanDays),
+				})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return time.Time{}, response.PolicyViolationError(violations)
+	}
+	return dueDate, nil
+}
+
+func (s *CirculationPolicyServiceImpl) EvaluateRenewal(ctx context.Context, user model.User, book model.Book, borrowing model.Borrowing) (time.Time, *response.CustomError) {
+	policy, customErr := s.findPolicy(s.DB, user, book)
+	if customErr != nil {
+		return time.Time{}, customErr
+	}
+
+	violations, err := s.checkPatron(s.DB, user, policy, borrowing.Id)
+	if err != nil {
+		return time.Time{}, response.RepositoryError(err.Error())
+	}
+
+	if borrowing.RenewalCount >= policy.MaxRenewals {
+		violations = append(violations, web.PolicyViolation{
+			Code:    ViolationMaxRenewals,
+			Message: fmt.Sprintf("This loan has been renewed %d of %d allowed times", borrowing.RenewalCount, policy.MaxRenewals),
+		})
+	}
+
+	if len(violations) > 0 {
+		return time.Time{}, response.PolicyViolationError(violations)
+	}
+	return renewalDueDate(borrowing.DueDate, time.Now(), policy), nil
+}
+
+// renewalDueDate extends a loan by LoanDays from its current due date, or
+// from now when it is overdue, without going past MaxLoanDays from now. A
+// renewal never brings the due date forward.
+func renewalDueDate(dueDate time.Time, now time.Time, policy *model.CirculationPolicy) time.Time {
+	extended := endOfDay(later(dueDate, now).AddDate(0, 0, policy.LoanDays))
+	limit := endOfDay(now.AddDate(0, 0, policy.MaxLoanDays))
+	if extended.After(limit) {
+		extended = limit
+	}
+	return later(extended, dueDate)
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func (s *CirculationPolicyServiceImpl) findPolicy(db *gorm.DB, user model.User, book model.Book) (*model.CirculationPolicy, *response.CustomError) {
+	var policy model.CirculationPolicy
+	err := s.CirculationPolicyRepository.FindMatching(db, &policy, user.PatronType, book.Category)
+	if err != nil {
+		return nil, response.PolicyViolationError([]web.PolicyViolation{{
+			Code:    ViolationNoPolicy,
+			Message: err.Error(),
+		}})
+	}
+	return &policy, nil
+}
+
+// checkPatron evaluates the blocks that depend on the patron's standing
+// rather than on the particular loan. The loan being renewed, if any, is
+// left out of the overdue check.
+func (s *CirculationPolicyServiceImpl) checkPatron(db *gorm.DB, user model.User, policy *model.CirculationPolicy, renewingId int) ([]web.PolicyViolation, error) {
+	violations := []web.PolicyViolation{}
+
+	if user.Status == model.UserStatusSuspended || user.Status == model.UserStatusExpired {
+		violations = append(violations, web.PolicyViolation{
+			Code:    ViolationAccountInactive,
+			Message: fmt.Sprintf("Your account is %s", user.Status),
+		})
+	}
+
+	if policy.BlockOnOverdue {
+		var overdue int64
+		err := s.BorrowingRepository.CountOverdueByUserId(db, &overdue, user.Id, time.Now(), renewingId)
+		if err != nil {
+			return nil, err
+		}
+		if overdue > 0 {
+			violations = append(violations, web.PolicyViolation{
+				Code:    ViolationOverdueItems,
+				Message: fmt.Sprintf("You have %d overdue items", overdue),
+			})
+		}
+	}
+
+	var outstanding int64
+	err := s.FineRepository.SumOutstandingByUserId(db, &outstanding, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if outstanding > policy.MaxFineCents {
+		violations = append(violations, web.PolicyViolation{
+			Code:    ViolationOutstandingFines,
+			Message: fmt.Sprintf("Outstanding fines of %d cents exceed the limit of %d cents", outstanding, policy.MaxFineCents),
+		})
+	}
+
+	return violations, nil
+}
+
+func toCirculationPolicyResponse(policy model.CirculationPolicy) web.CirculationPolicyResponse {
+	return web.CirculationPolicyResponse{
+		Id:             policy.Id,
+		PatronType:     policy.PatronType,
+		BookCategory:   policy.BookCategory,
+		LoanDays:       policy.LoanDays,
+		MaxLoanDays:    policy.MaxLoanDays,
+		MaxLoans:       policy.MaxLoans,
+		MaxRenewals:    policy.MaxRenewals,
+		MaxFineCents:   policy.MaxFineCents,
+		BlockOnOverdue: policy.BlockOnOverdue,
+	}
+}
+
+func endOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 23, 59, 59, 0, t.Location())
+}
@@ -0,0 +1,17 @@
+package service
+
+func uniqueIds(ids []int) []int {
+	seen := map[int]bool{}
+	result := []int{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func countUnique(ids []int) int {
+	return len(uniqueIds(ids))
+}
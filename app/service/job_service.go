@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/scheduler"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+
+	"gorm.io/gorm"
+)
+
+type JobService interface {
+	FindAll(ctx context.Context) ([]web.JobResponse, *response.CustomError)
+	FindRuns(ctx context.Context, filter *web.JobRunFilter) (*web.PageResponse, *response.CustomError)
+	Trigger(ctx context.Context, name string) (*web.JobRunResponse, *response.CustomError)
+}
+
+type JobServiceImpl struct {
+	Scheduler        *scheduler.Scheduler
+	JobRunRepository repository.JobRunRepository
+	DB               *gorm.DB
+}
+
+func NewJobService(scheduler *scheduler.Scheduler, jobRunRepository repository.JobRunRepository, DB *gorm.DB) JobService {
+	return &JobServiceImpl{
+		Scheduler:        scheduler,
+		JobRunRepository: jobRunRepository,
+		DB:               DB,
+	}
+}
+
+func (s *JobServiceImpl) FindAll(ctx context.Context) ([]web.JobResponse, *response.CustomError) {
+	jobResponses := []web.JobResponse{}
+	for _, job := range s.Scheduler.Jobs() {
+		jobResponse := web.JobResponse{
+			Name:      job.Name,
+			Schedule:  job.Spec,
+			NextRunAt: job.NextRunAt,
+		}
+		jobResponses = append(jobResponses, jobResponse)
+	}
+
+	return jobResponses, nil
+}
+
+func (s *JobServiceImpl) FindRuns(ctx context.Context, filter *web.JobRunFilter) (*web.PageResponse, *response.CustomError) {
+	page := filter.PageRequest.Normalize()
+
+	var jobRuns []model.JobRun
+	var total int64
+	err := s.JobRunRepository.FindAll(s.DB, &jobRuns, &total, &model.JobRunFilter{
+		JobName: filter.Job,
+		Status:  filter.Status,
+		Limit:   page.Limit,
+		Offset:  page.Offset(),
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	jobRunResponses := []web.JobRunResponse{}
+	for _, jobRun := range jobRuns {
+		jobRunResponses = append(jobRunResponses, toJobRunResponse(jobRun))
+	}
+
+	return &web.PageResponse{
+		Items: jobRunResponses,
+		Page:  page.Page,
+		Limit: page.Limit,
+		Total: total,
+	}, nil
+}
+
+func (s *JobServiceImpl) Trigger(ctx context.Context, name string) (*web.JobRunResponse, *response.CustomError) {
+	jobRun, err := s.Scheduler.Trigger(ctx, name)
+	if errors.Is(err, scheduler.ErrUnknownJob) {
+		return nil, response.NotFoundError("Job not found!")
+	}
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	jobRunResponse := toJobRunResponse(*jobRun)
+	return &jobRunResponse, nil
+}
+
+func toJobRunResponse(jobRun model.JobRun) web.JobRunResponse {
+	return web.JobRunResponse{
+		Id:          jobRun.Id,
+		Job:         jobRun.JobName,
+		ScheduledAt: jobRun.ScheduledAt,
+		TriggeredBy: jobRun.TriggeredBy,
+		ActorId:     jobRun.ActorId,
+		Status:      jobRun.Status,
+		Instance:    jobRun.Instance,
+		Error:       jobRun.Error,
+		StartedAt:   jobRun.StartedAt,
+		FinishedAt:  jobRun.FinishedAt,
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/response"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// An account email is locked after five consecutive failed logins and a
+	// client IP after twenty. Every further failure doubles the lockout,
+	// starting at thirty seconds and capped at an hour. Counters left alone
+	// for a day start over.
+	accountFailureLimit = 5
+	ipFailureLimit      = 20
+	loginLockoutBase    = 30 * time.Second
+	loginLockoutMax     = time.Hour
+	loginFailureWindow  = 24 * time.Hour
+)
+
+const (
+	// A client IP may ask for ten password reset emails, after which it is
+	// locked for an hour. The count starts over after an hour without
+	// requests.
+	passwordResetIpLimit = 10
+	passwordResetWindow  = time.Hour
+)
+
+const (
+	loginFailurePassword        = "password"
+	loginFailureTwoFactor       = "two_factor"
+	loginFailureCurrentPassword = "current_password"
+)
+
+// checkLoginThrottle rejects a login while the email or the client IP is
+// locked out.
+func checkLoginThrottle(db *gorm.DB, loginThrottleRepository repository.LoginThrottleRepository, now time.Time, email string, ipAddress string) *response.CustomError {
+	accountKey, ipKey := loginThrottleKeys(email, ipAddress)
+	until := lockedUntil(db, loginThrottleRepository, now, accountKey, ipKey)
+	if until == nil {
+		return nil
+	}
+	retryAfter := int(math.Ceil(until.Sub(now).Seconds()))
+	return response.TooManyRequestsError(map[string]int{"retry_after": retryAfter}, "Too many failed login attempts, try again later")
+}
+
+// loginThrottleKeys returns the throttle keys for the email and the client
+// IP of a login request.
+func loginThrottleKeys(email string, ipAddress string) (string, string) {
+	return "email:" + strings.ToLower(strings.TrimSpace(email)), "ip:" + ipAddress
+}
+
+// lockedUntil returns the latest lockout still running on any of keys. A
+// throttle that cannot be read does not block the login.
+func lockedUntil(db *gorm.DB, loginThrottleRepository repository.LoginThrottleRepository, now time.Time, keys ...string) *time.Time {
+	var lockedUntil *time.Time
+	for _, key := range keys {
+		var throttle model.LoginThrottle
+		if err := loginThrottleRepository.Find(db, &throttle, key); err != nil {
+			continue
+		}
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) && (lockedUntil == nil || throttle.LockedUntil.After(*lockedUntil)) {
+			lockedUntil = throttle.LockedUntil
+		}
+	}
+	return lockedUntil
+}
+
+// recordLoginFailure counts a failed login against the account email and the
+// client IP, locks either once it is over its limit and writes the audit
+// entry. userId is zero for unknown emails, and reason tells a wrong
+// password from a wrong two-factor code. Failures are logged rather than
+// returned so the caller answers the same way whatever happens here.
+func recordLoginFailure(ctx context.Context, db *gorm.DB, loginThrottleRepository repository.LoginThrottleRepository, auditLogRepository repository.AuditLogRepository, now time.Time, email string, ipAddress string, userId int, reason string) {
+	accountKey, ipKey := loginThrottleKeys(email, ipAddress)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		limits := map[string]int{accountKey: accountFailureLimit, ipKey: ipFailureLimit}
+		for key, limit := range limits {
+			var throttle model.LoginThrottle
+			err := loginThrottleRepository.RegisterFailure(tx, &throttle, key, now, now.Add(-loginFailureWindow))
+			if err != nil {
+				return err
+			}
+			if throttle.Failures < limit {
+				continue
+			}
+			err = loginThrottleRepository.Lock(tx, key, now.Add(loginLockout(throttle.Failures-limit)))
+			if err != nil {
+				return err
+			}
+		}
+		after := map[string]any{"Email": email, "IpAddress": ipAddress, "Reason": reason}
+		return recordAudit(ctx, tx, auditLogRepository, model.AuditActionLoginFailed, model.AuditEntityUser, userId, nil, after)
+	})
+	if err != nil {
+		log.Printf("login throttle: record failed login from %s: %v", ipAddress, err)
+	}
+}
+
+// loginLockout returns how long to lock a key that is excess failures past
+// its limit.
+func loginLockout(excess int) time.Duration {
+	lockout := loginLockoutBase
+	for i := 0; i < excess && lockout < loginLockoutMax; i++ {
+		lockout *= 2
+	}
+	return min(lockout, loginLockoutMax)
+}
+
+// throttleRequest counts a request against key and rejects it while key is
+// locked. Once the count passes limit the key is locked for window. A
+// throttle that cannot be updated does not block the request.
+func throttleRequest(db *gorm.DB, loginThrottleRepository repository.LoginThrottleRepository, now time.Time, key string, limit int, window time.Duration) *response.CustomError {
+	if until := lockedUntil(db, loginThrottleRepository, now, key); until != nil {
+		retryAfter := int(math.Ceil(until.Sub(now).Seconds()))
+		return response.TooManyRequestsError(map[string]int{"retry_after": retryAfter}, "Too many requests, try again later")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var throttle model.LoginThrottle
+		err := loginThrottleRepository.RegisterFailure(tx, &throttle, key, now, now.Add(-window))
+		if err != nil || throttle.Failures < limit {
+			return err
+		}
+		return loginThrottleRepository.Lock(tx, key, now.Add(window))
+	})
+	if err != nil {
+		log.Printf("request throttle: count request for %s: %v", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLockout(t *testing.T) {
+	tests := []struct {
+		excess int
+		want   time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		// 64 minutes is over the cap.
+		{7, time.Hour},
+		{8, time.Hour},
+		{1000, time.Hour},
+	}
+	for _, test := range tests {
+		if got := loginLockout(test.excess); got != test.want {
+			t.Errorf("loginLockout(%d) = %v, want %v", test.excess, got, test.want)
+		}
+	}
+}
+
+func TestLoginLockoutNeverShrinks(t *testing.T) {
+	previous := time.Duration(0)
+	for excess := 0; excess < 50; excess++ {
+		lockout := loginLockout(excess)
+		if lockout < previous {
+			t.Fatalf("loginLockout(%d) = %v, shorter than %v before it", excess, lockout, previous)
+		}
+		if lockout > loginLockoutMax {
+			t.Fatalf("loginLockout(%d) = %v, over the %v cap", excess, lockout, loginLockoutMax)
+		}
+		previous = lockout
+	}
+}
+
+func TestLoginThrottleKeys(t *testing.T) {
+	tests := []struct {
+		email      string
+		ipAddress  string
+		accountKey string
+		ipKey      string
+	}{
+		{"patron@example.com", "203.0.113.7", "email:patron@example.com", "ip:203.0.113.7"},
+		{"  Patron@Example.COM ", "203.0.113.7", "email:patron@example.com", "ip:203.0.113.7"},
+		{"patron@example.com", "2001:db8::1", "email:patron@example.com", "ip:2001:db8::1"},
+	}
+	for _, test := range tests {
+		accountKey, ipKey := loginThrottleKeys(test.email, test.ipAddress)
+		if accountKey != test.accountKey || ipKey != test.ipKey {
+			t.Errorf("loginThrottleKeys(%q, %q) = %q, %q, want %q, %q", test.email, test.ipAddress, accountKey, ipKey, test.accountKey, test.ipKey)
+		}
+	}
+}
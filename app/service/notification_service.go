@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/notification"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/helper/mail"
+	"kukuh/go-gin-library-project/response"
+	"math"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const defaultDueSoonDays = 3
+
+type NotificationService interface {
+	FindPreference(ctx context.Context, userId int) (*web.NotificationPreferenceResponse, *response.CustomError)
+	UpdatePreference(ctx context.Context, request *web.NotificationPreferenceRequest) (*web.NotificationPreferenceResponse, *response.CustomError)
+	HandleEvent(ctx context.Context, tx *gorm.DB, event model.OutboxEvent) error
+	SendDueSoon(ctx context.Context, now time.Time) (int, *response.CustomError)
+	SendOverdue(ctx context.Context) (int, *response.CustomError)
+	SendAccountEmail(ctx context.Context, event string, user model.User, link string, expiresAt time.Time) error
+}
+
+type NotificationServiceImpl struct {
+	NotificationRepository repository.NotificationRepository
+	BorrowingRepository    repository.BorrowingRepository
+	BookRepository         repository.BookRepository
+	UserRepository         repository.UserRepository
+	Sender                 mail.Sender
+	DB                     *gorm.DB
+	Validate               *validator.Validate
+}
+
+func NewNotificationService(notificationRepository repository.NotificationRepository, borrowingRepository repository.BorrowingRepository, bookRepository repository.BookRepository, userRepository repository.UserRepository, sender mail.Sender, DB *gorm.DB, validate *validator.Validate) NotificationService {
+	return &NotificationServiceImpl{
+		NotificationRepository: notificationRepository,
+		BorrowingRepository:    borrowingRepository,
+		BookRepository:         bookRepository,
+		UserRepository:         userRepository,
+		Sender:                 sender,
+		DB:                     DB,
+		Validate:               validate,
+	}
+}
+
+func (s *NotificationServiceImpl) FindPreference(ctx context.Context, userId int) (*web.NotificationPreferenceResponse, *response.CustomError) {
+	preference := s.preference(userId)
+	return toNotificationPreferenceResponse(preference), nil
+}
+
+func (s *NotificationServiceImpl) UpdatePreference(ctx context.Context, request *web.NotificationPreferenceRequest) (*web.NotificationPreferenceResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	preference := model.NotificationPreference{
+		UserId:       request.UserId,
+		EmailEnabled: request.EmailEnabled,
+		LoanCreated:  request.LoanCreated,
+		DueSoon:      request.DueSoon,
+		Overdue:      request.Overdue,
+		Returned:     request.Returned,
+		DueSoonDays:  request.DueSoonDays,
+		UpdatedAt:    time.Now(),
+	}
+	err = s.NotificationRepository.SavePreference(s.DB, &preference)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return toNotificationPreferenceResponse(preference), nil
+}
+
+// notificationEvents maps the domain events patrons are emailed about to
+// their notification.
+var notificationEvents = map[string]string{
+	model.EventBorrowingCreated:  model.NotificationLoanCreated,
+	model.EventBorrowingReturned: model.NotificationReturned,
+	model.EventBorrowingOverdue:  model.NotificationOverdue,
+}
+
+// HandleEvent emails the patron about loan events unless they opted out or
+// the same notification was already sent. The mail goes out after the
+// dispatcher's transaction commits.
+func (s *NotificationServiceImpl) HandleEvent(ctx context.Context, tx *gorm.DB, event model.OutboxEvent) error {
+	notificationEvent, ok := notificationEvents[event.Type]
+	if !ok {
+		return nil
+	}
+
+	var data web.BorrowingResponse
+	err := json.Unmarshal([]byte(event.Payload), &data)
+	if err != nil {
+		return err
+	}
+
+	borrowing := model.Borrowing{
+		Id:           data.Id,
+		UserId:       data.UserId,
+		BookId:       data.BookId,
+		BorrowDate:   data.BorrowDate,
+		DueDate:      data.DueDate,
+		Status:       model.BorrowingStatus(data.Status),
+		ReturnDate:   data.ReturnDate,
+		RenewalCount: data.RenewalCount,
+	}
+	return helper.AfterCommit(ctx, func(ctx context.Context) error {
+		_, err := s.notify(ctx, notificationEvent, borrowing, time.Now())
+		return err
+	})
+}
+
+func (s *NotificationServiceImpl) SendDueSoon(ctx context.Context, now time.Time) (int, *response.CustomError) {
+	var borrowings []model.Borrowing
+	err := s.BorrowingRepository.FindDueSoon(s.DB, &borrowings, now, defaultDueSoonDays)
+	if err != nil {
+		return 0, response.RepositoryError(err.Error())
+	}
+	return s.notifyAll(ctx, model.NotificationDueSoon, borrowings, now)
+}
+
+func (s *NotificationServiceImpl) SendOverdue(ctx context.Context) (int, *response.CustomError) {
+	var borrowings []model.Borrowing
+	err := s.BorrowingRepository.FindByStatus(s.DB, &borrowings, model.BorrowingStatusOverdue)
+	if err != nil {
+		return 0, response.RepositoryError(err.Error())
+	}
+	return s.notifyAll(ctx, model.NotificationOverdue, borrowings, time.Now())
+}
+
+// SendAccountEmail mails the user a link to act on their account, such as
+// verifying their address.
+func (s *NotificationServiceImpl) SendAccountEmail(ctx context.Context, event string, user model.User, link string, expiresAt time.Time) error {
+	message, err := notification.Render(event, user.Email, notification.Data{
+		Name:      user.Name,
+		Link:      link,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	return s.Sender.Send(ctx, *message)
+}
+
+// notifyAll keeps going past individual failures so one bad address does not
+// hold up everyone else's reminders; the first error is reported at the end.
+func (s *NotificationServiceImpl) notifyAll(ctx context.Context, event string, borrowings []model.Borrowing, now time.Time) (int, *response.CustomError) {
+	sent := 0
+	var firstErr error
+	for _, borrowing := range borrowings {
+		ok, err := s.notify(ctx, event, borrowing, now)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if ok {
+			sent++
+		}
+	}
+	if firstErr != nil {
+		return sent, response.GeneralError(firstErr.Error())
+	}
+	return sent, nil
+}
+
+func (s *NotificationServiceImpl) notify(ctx context.Context, event string, borrowing model.Borrowing, now time.Time) (bool, error) {
+	preference := s.preference(borrowing.UserId)
+	if !allowsNotification(preference, event) {
+		return false, nil
+	}
+
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, borrowing.UserId)
+	if err != nil {
+		return false, err
+	}
+	var book model.Book
+	err = s.BookRepository.Find(s.DB, &book, borrowing.BookId)
+	if err != nil {
+		return false, err
+	}
+
+	record := model.Notification{
+		UserId:      borrowing.UserId,
+		BorrowingId: borrowing.Id,
+		Event:       event,
+		DueDate:     borrowing.DueDate,
+		Recipient:   user.Email,
+		Status:      model.NotificationStatusPending,
+		CreatedAt:   now,
+	}
+	claimed, err := s.NotificationRepository.Claim(s.DB, &record)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	message, err := notification.Render(event, user.Email, notification.Data{
+		Name:       user.Name,
+		BookTitle:  book.Title,
+		BorrowDate: borrowing.BorrowDate,
+		DueDate:    borrowing.DueDate,
+		ReturnDate: borrowing.ReturnDate,
+		DaysLeft:   daysBetween(now, borrowing.DueDate),
+	})
+	if err == nil {
+		record.Subject = message.Subject
+		err = s.Sender.Send(ctx, *message)
+	}
+
+	record.Status = model.NotificationStatusSent
+	if err != nil {
+		record.Status = model.NotificationStatusFailed
+		record.Error = err.Error()
+	} else {
+		sentAt := time.Now()
+		record.SentAt = &sentAt
+	}
+	updateErr := s.NotificationRepository.UpdateStatus(s.DB, &record)
+	if err == nil {
+		err = updateErr
+	}
+
+	return err == nil, err
+}
+
+// preference returns the patron's saved preferences, or the defaults of
+// every notification enabled when they have never changed them.
+func (s *NotificationServiceImpl) preference(userId int) model.NotificationPreference {
+	var preference model.NotificationPreference
+	err := s.NotificationRepository.FindPreference(s.DB, &preference, userId)
+	if err != nil {
+		return model.NotificationPreference{
+			UserId:       userId,
+			EmailEnabled: true,
+			LoanCreated:  true,
+			DueSoon:      true,
+			Overdue:      true,
+			Returned:     true,
+			DueSoonDays:  defaultDueSoonDays,
+		}
+	}
+	return preference
+}
+
+func allowsNotification(preference model.NotificationPreference, event string) bool {
+	if !preference.EmailEnabled {
+		return false
+	}
+	switch event {
+	case model.NotificationLoanCreated:
+		return preference.LoanCreated
+	case model.NotificationDueSoon:
+		return preference.DueSoon
+	case model.NotificationOverdue:
+		return preference.Overdue
+	case model.NotificationReturned:
+		return preference.Returned
+	}
+	return false
+}
+
+// daysBetween counts calendar days from now until t in local time.
+func daysBetween(now time.Time, t time.Time) int {
+	now, t = now.Local(), t.Local()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	to := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+func toNotificationPreferenceResponse(preference model.NotificationPreference) *web.NotificationPreferenceResponse {
+	return &web.NotificationPreferenceResponse{
+		EmailEnabled: preference.EmailEnabled,
+		LoanCreated:  preference.LoanCreated,
+		DueSoon:      preference.DueSoon,
+		Overdue:      preference.Overdue,
+		Returned:     preference.Returned,
+		DueSoonDays:  preference.DueSoonDays,
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/helper/oidc"
+	"kukuh/go-gin-library-project/helper/token"
+	"kukuh/go-gin-library-project/response"
+	"log"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// OidcLoginTTL is how long the user has to log in at the identity provider.
+const OidcLoginTTL = 10 * time.Minute
+
+type OidcService interface {
+	Start(ctx context.Context) (*web.OidcStartResponse, *response.CustomError)
+	Callback(ctx context.Context, request *web.OidcCallbackRequest) (*web.LoginUserResponse, *response.CustomError)
+}
+
+type OidcServiceImpl struct {
+	Provider            *oidc.Provider
+	OidcLoginRepository repository.OidcLoginRepository
+	UserService         UserService
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+}
+
+func NewOidcService(provider *oidc.Provider, oidcLoginRepository repository.OidcLoginRepository, userService UserService, DB *gorm.DB, validate *validator.Validate) OidcService {
+	return &OidcServiceImpl{
+		Provider:            provider,
+		OidcLoginRepository: oidcLoginRepository,
+		UserService:         userService,
+		DB:                  DB,
+		Validate:            validate,
+	}
+}
+
+// Start records a new authorization request and returns the provider URL
+// to send the user to, along with the state the caller must also keep in
+// the user's browser.
+func (s *OidcServiceImpl) Start(ctx context.Context) (*web.OidcStartResponse, *response.CustomError) {
+	state := helper.RandomHex(32)
+	now := time.Now()
+	oidcLogin := model.OidcLogin{
+		StateHash:    token.HashOpaqueToken(state),
+		Nonce:        helper.RandomHex(16),
+		CodeVerifier: oidc.NewCodeVerifier(),
+		ExpiresAt:    now.Add(OidcLoginTTL),
+		CreatedAt:    now,
+	}
+
+	authorizationUrl, err := s.Provider.AuthCodeURL(ctx, state, oidcLogin.Nonce, oidcLogin.CodeVerifier)
+	if err != nil {
+		log.Printf("oidc service: start login: %v", err)
+		return nil, response.GeneralError("Identity provider is unavailable")
+	}
+	err = s.OidcLoginRepository.Save(s.DB, &oidcLogin)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return &web.OidcStartResponse{
+		AuthorizationUrl: authorizationUrl,
+		State:            state,
+	}, nil
+}
+
+// Callback finishes the login: the state must match the one kept in the
+// browser and an unanswered request, and the code is exchanged with the
+// PKCE verifier for an ID token carrying the request's nonce.
+func (s *OidcServiceImpl) Callback(ctx context.Context, request *web.OidcCallbackRequest) (*web.LoginUserResponse, *response.CustomError) {
+	if request.Error != "" {
+		return nil, response.UnauthorizedError("Identity provider refused the login: " + request.Error + " " + request.ErrorDescription)
+	}
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	if subtle.ConstantTimeCompare([]byte(request.State), []byte(request.StateCookie)) != 1 {
+		return nil, response.UnauthorizedError("Login state does not match this browser")
+	}
+
+	now := time.Now()
+	var oidcLogin model.OidcLogin
+	err = s.OidcLoginRepository.FindByStateHash(s.DB, &oidcLogin, token.HashOpaqueToken(request.State))
+	if err != nil || oidcLogin.UsedAt != nil || now.After(oidcLogin.ExpiresAt) {
+		return nil, response.UnauthorizedError("Login request is invalid or has expired")
+	}
+	err = s.OidcLoginRepository.Consume(s.DB, oidcLogin.Id, now)
+	if err != nil {
+		return nil, response.UnauthorizedError("Login request is invalid or has expired")
+	}
+
+	claims, err := s.Provider.Exchange(ctx, request.Code, oidcLogin.CodeVerifier, oidcLogin.Nonce)
+	if err != nil {
+		log.Printf("oidc service: exchange code: %v", err)
+		return nil, response.UnauthorizedError("Identity provider login could not be verified")
+	}
+
+	return s.UserService.LoginExternal(ctx, claims)
+}
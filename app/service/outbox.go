@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/helper"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordEvent writes a domain event to the outbox. Like recordAudit it must
+// be called with the transaction of the change it describes, so the event
+// exists exactly when the change commits. Subscribers receive it from the
+// dispatcher afterwards; data becomes the event payload.
+func recordEvent(ctx context.Context, db *gorm.DB, outboxRepository repository.OutboxRepository, eventType string, entity string, entityId int, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var actorId *int
+	if id, err := strconv.Atoi(helper.AuthId(ctx)); err == nil {
+		actorId = &id
+	}
+
+	now := time.Now()
+	event := model.OutboxEvent{
+		EventId:       helper.RandomHex(16),
+		Type:          eventType,
+		Entity:        entity,
+		EntityId:      entityId,
+		Payload:       string(payload),
+		ActorId:       actorId,
+		RequestId:     helper.RequestId(ctx),
+		Status:        model.OutboxStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	return outboxRepository.Save(db, &event)
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type PublisherService interface {
+	Create(ctx context.Context, request *web.PublisherCreate) (*web.PublisherResponse, *response.CustomError)
+	Update(ctx context.Context, request *web.PublisherUpdate) (*web.PublisherResponse, *response.CustomError)
+	Find(ctx context.Context, publisherId int) (*web.PublisherResponse, *response.CustomError)
+	Delete(ctx context.Context, publisherId int) *response.CustomError
+	FindAll(ctx context.Context) ([]web.PublisherResponse, *response.CustomError)
+}
+
+type PublisherServiceImpl struct {
+	PublisherRepository repository.PublisherRepository
+	AuditLogRepository  repository.AuditLogRepository
+	DB                  *gorm.DB
+	Validate            *validator.Validate
+}
+
+func NewPublisherService(publisherRepository repository.PublisherRepository, auditLogRepository repository.AuditLogRepository, DB *gorm.DB, validate *validator.Validate) PublisherService {
+	return &PublisherServiceImpl{
+		PublisherRepository: publisherRepository,
+		AuditLogRepository:  auditLogRepository,
+		DB:                  DB,
+		Validate:            validate,
+	}
+}
+
+func (s *PublisherServiceImpl) Create(ctx context.Context, request *web.PublisherCreate) (*web.PublisherResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	publisher := model.Publisher{
+		Name:      request.Name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.PublisherRepository.Save(tx, &publisher)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityPublisher, publisher.Id, nil, &publisher)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	publisherResponse := web.PublisherResponse{
+		Id:   publisher.Id,
+		Name: publisher.Name,
+	}
+
+	return &publisherResponse, nil
+}
+
+func (s *PublisherServiceImpl) Update(ctx context.Context, request *web.PublisherUpdate) (*web.PublisherResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var publisher model.Publisher
+
+	err = s.PublisherRepository.Find(s.DB, &publisher, request.Id)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	before := publisher
+	publisher.Name = request.Name
+	publisher.UpdatedAt = time.Now()
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.PublisherRepository.Update(tx, &publisher)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityPublisher, publisher.Id, &before, &publisher)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	publisherResponse := web.PublisherResponse{
+		Id:   publisher.Id,
+		Name: publisher.Name,
+	}
+
+	return &publisherResponse, nil
+}
+
+func (s *PublisherServiceImpl) Find(ctx context.Context, publisherId int) (*web.PublisherResponse, *response.CustomError) {
+	var publisher model.Publisher
+
+	err := s.PublisherRepository.Find(s.DB, &publisher, publisherId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	publisherResponse := web.PublisherResponse{
+		Id:   publisher.Id,
+		Name: publisher.Name,
+	}
+
+	return &publisherResponse, nil
+}
+
+func (s *PublisherServiceImpl) Delete(ctx context.Context, publisherId int) *response.CustomError {
+	var publisher model.Publisher
+
+	err := s.PublisherRepository.Find(s.DB, &publisher, publisherId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+
+	linked := false
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var books int64
+		err := s.PublisherRepository.CountBooks(tx, &books, publisher.Id)
+		if err != nil {
+			return err
+		}
+		if books > 0 {
+			linked = true
+			return errors.New("publisher is linked to books")
+		}
+		err = s.PublisherRepository.Delete(tx, publisher.Id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionDelete, model.AuditEntityPublisher, publisher.Id, &publisher, nil)
+	})
+	if linked {
+		return response.ConflictError("Publisher is linked to books, including deleted ones, and cannot be deleted until they are unlinked or purged")
+	}
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	return nil
+}
+
+func (s *PublisherServiceImpl) FindAll(ctx context.Context) ([]web.PublisherResponse, *response.CustomError) {
+	var publishers []model.Publisher
+	err := s.PublisherRepository.FindAll(s.DB, &publishers)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	publisherResponses := []web.PublisherResponse{}
+	for _, publisher := range publishers {
+		publisherResponse := web.PublisherResponse{
+			Id:   publisher.Id,
+			Name: publisher.Name,
+		}
+		publisherResponses = append(publisherResponses, publisherResponse)
+	}
+
+	return publisherResponses, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/response"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type SubjectService interface {
+	Create(ctx context.Context, request *web.SubjectCreate) (*web.SubjectResponse, *response.CustomError)
+	Update(ctx context.Context, request *web.SubjectUpdate) (*web.SubjectResponse, *response.CustomError)
+	Find(ctx context.Context, subjectId int) (*web.SubjectResponse, *response.CustomError)
+	Delete(ctx context.Context, subjectId int) *response.CustomError
+	FindAll(ctx context.Context) ([]web.SubjectResponse, *response.CustomError)
+}
+
+type SubjectServiceImpl struct {
+	SubjectRepository  repository.SubjectRepository
+	AuditLogRepository repository.AuditLogRepository
+	DB                 *gorm.DB
+	Validate           *validator.Validate
+}
+
+func NewSubjectService(subjectRepository repository.SubjectRepository, auditLogRepository repository.AuditLogRepository, DB *gorm.DB, validate *validator.Validate) SubjectService {
+	return &SubjectServiceImpl{
+		SubjectRepository:  subjectRepository,
+		AuditLogRepository: auditLogRepository,
+		DB:                 DB,
+		Validate:           validate,
+	}
+}
+
+func (s *SubjectServiceImpl) Create(ctx context.Context, request *web.SubjectCreate) (*web.SubjectResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	subject := model.Subject{
+		Name:      request.Name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.SubjectRepository.Save(tx, &subject)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntitySubject, subject.Id, nil, &subject)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	subjectResponse := web.SubjectResponse{
+		Id:   subject.Id,
+		Name: subject.Name,
+	}
+
+	return &subjectResponse, nil
+}
+
+func (s *SubjectServiceImpl) Update(ctx context.Context, request *web.SubjectUpdate) (*web.SubjectResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var subject model.Subject
+
+	err = s.SubjectRepository.Find(s.DB, &subject, request.Id)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	before := subject
+	subject.Name = request.Name
+	subject.UpdatedAt = time.Now()
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.SubjectRepository.Update(tx, &subject)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntitySubject, subject.Id, &before, &subject)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	subjectResponse := web.SubjectResponse{
+		Id:   subject.Id,
+		Name: subject.Name,
+	}
+
+	return &subjectResponse, nil
+}
+
+func (s *SubjectServiceImpl) Find(ctx context.Context, subjectId int) (*web.SubjectResponse, *response.CustomError) {
+	var subject model.Subject
+
+	err := s.SubjectRepository.Find(s.DB, &subject, subjectId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	subjectResponse := web.SubjectResponse{
+		Id:   subject.Id,
+		Name: subject.Name,
+	}
+
+	return &subjectResponse, nil
+}
+
+func (s *SubjectServiceImpl) Delete(ctx context.Context, subjectId int) *response.CustomError {
+	var subject model.Subject
+
+	err := s.SubjectRepository.Find(s.DB, &subject, subjectId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+
+	linked := false
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var books int64
+		err := s.SubjectRepository.CountBooks(tx, &books, subject.Id)
+		if err != nil {
+			return err
+		}
+		if books > 0 {
+			linked = true
+			return errors.New("subject is linked to books")
+		}
+		err = s.SubjectRepository.Delete(tx, subject.Id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionDelete, model.AuditEntitySubject, subject.Id, &subject, nil)
+	})
+	if linked {
+		return response.ConflictError("Subject is linked to books, including deleted ones, and cannot be deleted until they are unlinked or purged")
+	}
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	return nil
+}
+
+func (s *SubjectServiceImpl) FindAll(ctx context.Context) ([]web.SubjectResponse, *response.CustomError) {
+	var subjects []model.Subject
+	err := s.SubjectRepository.FindAll(s.DB, &subjects)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	subjectResponses := []web.SubjectResponse{}
+	for _, subject := range subjects {
+		subjectResponse := web.SubjectResponse{
+			Id:   subject.Id,
+			Name: subject.Name,
+		}
+		subjectResponses = append(subjectResponses, subjectResponse)
+	}
+
+	return subjectResponses, nil
+}
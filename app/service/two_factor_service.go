@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/helper/password"
+	"kukuh/go-gin-library-project/helper/token"
+	"kukuh/go-gin-library-project/helper/totp"
+	"kukuh/go-gin-library-project/response"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	// Codes from one step either side of the current one are accepted to
+	// allow for clock drift between the server and the user's device.
+	totpSkew          = 1
+	recoveryCodeCount = 10
+)
+
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, userId int) (*web.TwoFactorEnrollResponse, *response.CustomError)
+	Confirm(ctx context.Context, userId int, request *web.TwoFactorCodeRequest) (*web.TwoFactorConfirmResponse, *response.CustomError)
+	Disable(ctx context.Context, userId int, request *web.TwoFactorDisableRequest) *response.CustomError
+	RegenerateRecoveryCodes(ctx context.Context, userId int, request *web.TwoFactorCodeRequest) (*web.TwoFactorRecoveryCodesResponse, *response.CustomError)
+	VerifyCode(ctx context.Context, tx *gorm.DB, user model.User, code string) error
+	Required(role string) bool
+	CheckEnrollment(ctx context.Context, userId int) *response.CustomError
+}
+
+type TwoFactorServiceImpl struct {
+	UserRepository          repository.UserRepository
+	RecoveryCodeRepository  repository.RecoveryCodeRepository
+	LoginThrottleRepository repository.LoginThrottleRepository
+	AuditLogRepository      repository.AuditLogRepository
+	PasswordHasher          *password.Hasher
+	Issuer                  string
+	RequiredRoles           []string
+	DB                      *gorm.DB
+	Validate                *validator.Validate
+}
+
+func NewTwoFactorService(userRepository repository.UserRepository, recoveryCodeRepository repository.RecoveryCodeRepository, loginThrottleRepository repository.LoginThrottleRepository, auditLogRepository repository.AuditLogRepository, passwordHasher *password.Hasher, issuer string, requiredRoles []string, DB *gorm.DB, validate *validator.Validate) TwoFactorService {
+	return &TwoFactorServiceImpl{
+		UserRepository:          userRepository,
+		RecoveryCodeRepository:  recoveryCodeRepository,
+		LoginThrottleRepository: loginThrottleRepository,
+		AuditLogRepository:      auditLogRepository,
+		PasswordHasher:          passwordHasher,
+		Issuer:                  issuer,
+		RequiredRoles:           requiredRoles,
+		DB:                      DB,
+		Validate:                validate,
+	}
+}
+
+// Enroll gives the user a new secret to add to their authenticator app.
+// Two-factor authentication stays off until the user confirms it with a
+// code, so calling Enroll again before then simply replaces the secret.
+func (s *TwoFactorServiceImpl) Enroll(ctx context.Context, userId int) (*web.TwoFactorEnrollResponse, *response.CustomError) {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+	if user.TotpEnabledAt != nil {
+		return nil, response.ConflictError("Two-factor authentication is already enabled")
+	}
+
+	user.TotpSecret = totp.NewSecret()
+	user.TotpLastStep = 0
+	user.UpdatedAt = time.Now()
+	err = s.UserRepository.UpdateTwoFactor(s.DB, &user)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return &web.TwoFactorEnrollResponse{
+		Secret:          user.TotpSecret,
+		ProvisioningUri: totp.ProvisioningURI(s.Issuer, user.Email, user.TotpSecret),
+	}, nil
+}
+
+func (s *TwoFactorServiceImpl) Confirm(ctx context.Context, userId int, request *web.TwoFactorCodeRequest) (*web.TwoFactorConfirmResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+	if user.TotpEnabledAt != nil {
+		return nil, response.ConflictError("Two-factor authentication is already enabled")
+	}
+	if user.TotpSecret == "" {
+		return nil, response.PreconditionFailedError("Start two-factor enrollment first")
+	}
+	step, ok := totp.Validate(user.TotpSecret, strings.TrimSpace(request.Code), time.Now(), totpSkew)
+	if !ok {
+		return nil, response.UnauthorizedError(ErrInvalidTwoFactorCode.Error())
+	}
+
+	before := user
+	now := time.Now()
+	user.TotpEnabledAt = &now
+	user.TotpLastStep = step
+	user.SessionVersion++
+	user.UpdatedAt = now
+	var recoveryCodes []string
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.UpdateTwoFactor(tx, &user)
+		if err != nil {
+			return err
+		}
+		recoveryCodes, err = s.replaceRecoveryCodes(tx, user.Id, now)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, &user)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	jwtToken, err := token.GenerateJwtToken(strconv.Itoa(user.Id), user.Role, user.SessionVersion)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	return &web.TwoFactorConfirmResponse{
+		Token:         jwtToken,
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// Disable turns two-factor authentication off. Wrong passwords and codes
+// count as failed logins, like they do when logging in.
+func (s *TwoFactorServiceImpl) Disable(ctx context.Context, userId int, request *web.TwoFactorDisableRequest) *response.CustomError {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return response.BadRequestError(err.Error())
+	}
+
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+	if user.TotpEnabledAt == nil {
+		return response.PreconditionFailedError("Two-factor authentication is not enabled")
+	}
+	if s.Required(user.Role) {
+		return response.ForbiddenError("Two-factor authentication is required for your role")
+	}
+	now := time.Now()
+	if customErr := checkLoginThrottle(s.DB, s.LoginThrottleRepository, now, user.Email, request.IpAddress); customErr != nil {
+		return customErr
+	}
+	if s.PasswordHasher.Verify(user.Password, request.CurrentPassword) != nil {
+		recordLoginFailure(ctx, s.DB, s.LoginThrottleRepository, s.AuditLogRepository, now, user.Email, request.IpAddress, user.Id, loginFailureCurrentPassword)
+		return response.UnauthorizedError("Current password is incorrect")
+	}
+
+	before := user
+	user.TotpSecret = ""
+	user.TotpEnabledAt = nil
+	user.TotpLastStep = 0
+	user.UpdatedAt = time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.VerifyCode(ctx, tx, before, request.Code)
+		if err != nil {
+			return err
+		}
+		err = s.UserRepository.UpdateTwoFactor(tx, &user)
+		if err != nil {
+			return err
+		}
+		err = s.RecoveryCodeRepository.DeleteByUserId(tx, user.Id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, &user)
+	})
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		recordLoginFailure(ctx, s.DB, s.LoginThrottleRepository, s.AuditLogRepository, now, user.Email, request.IpAddress, user.Id, loginFailureTwoFactor)
+		return response.UnauthorizedError(err.Error())
+	}
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	return nil
+}
+
+func (s *TwoFactorServiceImpl) RegenerateRecoveryCodes(ctx context.Context, userId int, request *web.TwoFactorCodeRequest) (*web.TwoFactorRecoveryCodesResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+	if user.TotpEnabledAt == nil {
+		return nil, response.PreconditionFailedError("Two-factor authentication is not enabled")
+	}
+	now := time.Now()
+	if customErr := checkLoginThrottle(s.DB, s.LoginThrottleRepository, now, user.Email, request.IpAddress); customErr != nil {
+		return nil, customErr
+	}
+
+	var recoveryCodes []string
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.VerifyCode(ctx, tx, user, request.Code)
+		if err != nil {
+			return err
+		}
+		recoveryCodes, err = s.replaceRecoveryCodes(tx, user.Id, now)
+		return err
+	})
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		recordLoginFailure(ctx, s.DB, s.LoginThrottleRepository, s.AuditLogRepository, now, user.Email, request.IpAddress, user.Id, loginFailureTwoFactor)
+		return nil, response.UnauthorizedError(err.Error())
+	}
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return &web.TwoFactorRecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code
+// and uses it up. Any code that is not accepted yields
+// ErrInvalidTwoFactorCode.
+func (s *TwoFactorServiceImpl) VerifyCode(ctx context.Context, tx *gorm.DB, user model.User, code string) error {
+	if user.TotpEnabledAt == nil {
+		return ErrInvalidTwoFactorCode
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.TotpSecret, code, time.Now(), totpSkew)
+		if !ok || s.UserRepository.UpdateTotpStep(tx, user.Id, step) != nil {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+	err := s.RecoveryCodeRepository.Consume(tx, user.Id, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorServiceImpl) Required(role string) bool {
+	return slices.Contains(s.RequiredRoles, role)
+}
+
+// CheckEnrollment rejects users whose role requires two-factor
+// authentication until they have enabled it.
+func (s *TwoFactorServiceImpl) CheckEnrollment(ctx context.Context, userId int) *response.CustomError {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return response.UnauthorizedError("User not found")
+	}
+	if s.Required(user.Role) && user.TotpEnabledAt == nil {
+		return response.ForbiddenError("Two-factor authentication must be enabled for your role")
+	}
+	return nil
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and returns a new
+// set. Only their hashes are stored, so this is the one chance to show them.
+func (s *TwoFactorServiceImpl) replaceRecoveryCodes(tx *gorm.DB, userId int, now time.Time) ([]string, error) {
+	err := s.RecoveryCodeRepository.DeleteByUserId(tx, userId)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code := helper.RandomHex(4) + "-" + helper.RandomHex(4)
+		err := s.RecoveryCodeRepository.Save(tx, &model.RecoveryCode{
+			UserId:    userId,
+			CodeHash:  hashRecoveryCode(code),
+			CreatedAt: now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		recoveryCodes = append(recoveryCodes, code)
+	}
+	return recoveryCodes, nil
+}
+
+// hashRecoveryCode ignores case and separators so codes can be typed the
+// way they are read.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return token.HashOpaqueToken(code)
+}
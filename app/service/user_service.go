@@ -2,37 +2,120 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"kukuh/go-gin-library-project/app/model"
 	"kukuh/go-gin-library-project/app/repository"
 	"kukuh/go-gin-library-project/app/web"
 	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/helper/oidc"
+	"kukuh/go-gin-library-project/helper/password"
 	"kukuh/go-gin-library-project/helper/token"
 	"kukuh/go-gin-library-project/response"
+	"log"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
 )
 
+const (
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+	emailChangeTTL       = 24 * time.Hour
+	// Accounts opened by staff get a week to choose a password.
+	accountSetupTTL = 7 * 24 * time.Hour
+	// Token emails of one kind go out at most once a minute and five times
+	// an hour per account.
+	tokenMailInterval = time.Minute
+	tokenMailLimit    = 5
+	// At most this many password reset emails are prepared at once.
+	backgroundMailLimit = 16
+	// A login challenge must be answered with a two-factor code within five
+	// minutes of the password.
+	loginChallengeTTL = 5 * time.Minute
+)
+
 type UserService interface {
 	Register(ctx context.Context, request *web.Register) (*web.UserResponse, *response.CustomError)
+	VerifyEmail(ctx context.Context, request *web.VerifyEmailRequest) (*web.UserResponse, *response.CustomError)
+	ResendVerification(ctx context.Context, request *web.ResendVerificationRequest) *response.CustomError
+	ForgotPassword(ctx context.Context, request *web.ForgotPasswordRequest) *response.CustomError
+	ResetPassword(ctx context.Context, request *web.ResetPasswordRequest) *response.CustomError
+	ValidateSession(ctx context.Context, userId int, sessionVersion int) *response.CustomError
 	Login(ctx context.Context, request *web.LoginUserRequest) (*web.LoginUserResponse, *response.CustomError)
+	LoginTwoFactor(ctx context.Context, request *web.LoginTwoFactorRequest) (*web.LoginUserResponse, *response.CustomError)
+	LoginExternal(ctx context.Context, claims *oidc.Claims) (*web.LoginUserResponse, *response.CustomError)
+	FindOwn(ctx context.Context, userId int) (*web.UserResponse, *response.CustomError)
+	ChangePassword(ctx context.Context, request *web.ChangePasswordRequest) (*web.ChangePasswordResponse, *response.CustomError)
+	ChangeEmail(ctx context.Context, request *web.ChangeEmailRequest) *response.CustomError
+	ConfirmEmailChange(ctx context.Context, request *web.VerifyEmailRequest) (*web.UserResponse, *response.CustomError)
 	UpdateUserOwn(ctx context.Context, request *web.UpdateUserRequest) (*web.UserResponse, *response.CustomError)
+	PatchUserOwn(ctx context.Context, userId int, patch []byte) (*web.UserResponse, *response.CustomError)
 	Delete(ctx context.Context, userId int) *response.CustomError
+	FindDeleted(ctx context.Context) ([]web.UserResponse, *response.CustomError)
+	Restore(ctx context.Context, userId int) (*web.UserResponse, *response.CustomError)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, *response.CustomError)
+	Unlock(ctx context.Context, userId int) *response.CustomError
+	FindAll(ctx context.Context, filter *web.UserFilter) (*web.PageResponse, *response.CustomError)
+	FindById(ctx context.Context, userId int) (*web.UserDetailResponse, *response.CustomError)
+	Create(ctx context.Context, request *web.CreateUserRequest) (*web.UserResponse, *response.CustomError)
+	UpdateAccount(ctx context.Context, userId int, request *web.UpdateAccountRequest) (*web.UserResponse, *response.CustomError)
+	UpdateStatus(ctx context.Context, userId int, request *web.UserStatusRequest) (*web.UserResponse, *response.CustomError)
+	BootstrapAdmin(ctx context.Context, name string, email string, password string) (bool, error)
 }
 
 type UserServiceImpl struct {
-	UserRepository repository.UserRepository
-	DB             *gorm.DB
-	Validate       *validator.Validate
+	UserRepository          repository.UserRepository
+	UserTokenRepository     repository.UserTokenRepository
+	LoginThrottleRepository repository.LoginThrottleRepository
+	UserIdentityRepository  repository.UserIdentityRepository
+	BorrowingRepository     repository.BorrowingRepository
+	FineRepository          repository.FineRepository
+	AuditLogRepository      repository.AuditLogRepository
+	OutboxRepository        repository.OutboxRepository
+	NotificationService     NotificationService
+	TwoFactorService        TwoFactorService
+	PasswordHasher          *password.Hasher
+	PasswordPolicy          *password.Policy
+	AppUrl                  string
+	DB                      *gorm.DB
+	Validate                *validator.Validate
+	// dummyPasswordHash is verified against when the email is unknown so
+	// that those logins take as long as a wrong password.
+	dummyPasswordHash string
+	// mailSlots bounds the password reset emails being prepared in the
+	// background at once.
+	mailSlots chan struct{}
 }
 
-func NewUserService(userRepository repository.UserRepository, DB *gorm.DB, validate *validator.Validate) UserService {
+func NewUserService(userRepository repository.UserRepository, userTokenRepository repository.UserTokenRepository, loginThrottleRepository repository.LoginThrottleRepository, userIdentityRepository repository.UserIdentityRepository, borrowingRepository repository.BorrowingRepository, fineRepository repository.FineRepository, auditLogRepository repository.AuditLogRepository, outboxRepository repository.OutboxRepository, notificationService NotificationService, twoFactorService TwoFactorService, passwordHasher *password.Hasher, passwordPolicy *password.Policy, appUrl string, DB *gorm.DB, validate *validator.Validate) UserService {
+	dummyPasswordHash, err := passwordHasher.Hash(helper.RandomHex(16))
+	if err != nil {
+		log.Printf("user service: dummy password hash: %v", err)
+	}
 	return &UserServiceImpl{
-		UserRepository: userRepository,
-		DB:             DB,
-		Validate:       validate,
+		UserRepository:          userRepository,
+		UserTokenRepository:     userTokenRepository,
+		LoginThrottleRepository: loginThrottleRepository,
+		UserIdentityRepository:  userIdentityRepository,
+		BorrowingRepository:     borrowingRepository,
+		FineRepository:          fineRepository,
+		AuditLogRepository:      auditLogRepository,
+		OutboxRepository:        outboxRepository,
+		NotificationService:     notificationService,
+		TwoFactorService:        twoFactorService,
+		PasswordHasher:          passwordHasher,
+		PasswordPolicy:          passwordPolicy,
+		AppUrl:                  appUrl,
+		DB:                      DB,
+		Validate:                validate,
+		dummyPasswordHash:       dummyPasswordHash,
+		mailSlots:               make(chan struct{}, backgroundMailLimit),
 	}
 }
 
@@ -42,61 +125,372 @@ func (s *UserServiceImpl) Register(ctx context.Context, request *web.Register) (
 		return nil, response.BadRequestError(err.Error())
 	}
 
-	password, err := helper.HashPassword(request.Password)
+	err = s.PasswordPolicy.Check(request.Password, request.Email)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	var count int64
+	err = s.UserRepository.CountByEmail(s.DB, &count, request.Email)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+	if count > 0 {
+		return nil, response.ConflictError("Email address is already in use")
+	}
+	passwordHash, err := s.PasswordHasher.Hash(request.Password)
 	if err != nil {
 		return nil, response.GeneralError(err.Error())
 	}
 	user := model.User{
-		Name:      request.Name,
-		Email:     request.Email,
-		Password:  password,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Name:       request.Name,
+		Email:      request.Email,
+		Password:   passwordHash,
+		Role:       model.RoleMember,
+		PatronType: model.PatronTypeStandard,
+		Status:     model.UserStatusPending,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
 	}
 
-	err = s.UserRepository.Save(s.DB, &user)
+	var plainToken string
+	var userToken model.UserToken
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.Save(tx, &user)
+		if err != nil {
+			return err
+		}
+		plainToken, userToken, err = s.issueToken(tx, user.Id, model.UserTokenEmailVerification, emailVerificationTTL)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityUser, user.Id, nil, &user)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventUserRegistered, model.AuditEntityUser, user.Id, toUserResponse(user))
+	})
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	userResponse := web.UserResponse{
-		Id:    user.Id,
-		Name:  user.Name,
-		Email: user.Email,
+	// The account exists either way; if the email fails the user can ask
+	// for it again.
+	s.sendVerification(ctx, user, plainToken, userToken)
+
+	userResponse := toUserResponse(user)
+	return &userResponse, nil
+}
+
+func (s *UserServiceImpl) VerifyEmail(ctx context.Context, request *web.VerifyEmailRequest) (*web.UserResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var user model.User
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := s.consumeToken(tx, model.UserTokenEmailVerification, request.Token)
+		if err != nil {
+			return err
+		}
+		err = s.UserRepository.FindById(tx, &user, userToken.UserId)
+		if err != nil {
+			return err
+		}
+		if user.Status != model.UserStatusPending {
+			return nil
+		}
+
+		userBefore := user
+		now := time.Now()
+		user.Status = model.UserStatusActive
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		err = s.UserRepository.VerifyEmail(tx, &user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &userBefore, &user)
+	})
+	if err != nil {
+		return nil, response.BadRequestError("Invalid or expired verification token!")
 	}
 
+	userResponse := toUserResponse(user)
 	return &userResponse, nil
 }
 
+// ResendVerification mails a new verification link to a pending account.
+// It succeeds whether or not the address belongs to one, and resends beyond
+// the limits are dropped silently, so the endpoint cannot be used to probe
+// for accounts.
+func (s *UserServiceImpl) ResendVerification(ctx context.Context, request *web.ResendVerificationRequest) *response.CustomError {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return response.BadRequestError(err.Error())
+	}
+
+	var user model.User
+	err = s.UserRepository.FindByEmail(s.DB, &user, request.Email)
+	if err != nil || user.Status != model.UserStatusPending {
+		return nil
+	}
+
+	plainToken, userToken, err := s.reissueToken(user.Id, model.UserTokenEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+	if userToken != nil {
+		s.sendVerification(ctx, user, plainToken, *userToken)
+	}
+	return nil
+}
+
+// ForgotPassword mails a password reset link. Like ResendVerification it
+// answers the same way for unknown addresses and throttled requests. The
+// lookup and the mail happen in the background, so the response time does
+// not tell registered addresses apart either. Each client IP may only ask
+// so often, and when too many requests are already being worked on new
+// ones are dropped; the patron can simply ask again.
+func (s *UserServiceImpl) ForgotPassword(ctx context.Context, request *web.ForgotPasswordRequest) *response.CustomError {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return response.BadRequestError(err.Error())
+	}
+	customErr := throttleRequest(s.DB, s.LoginThrottleRepository, time.Now(), "reset:ip:"+request.IpAddress, passwordResetIpLimit, passwordResetWindow)
+	if customErr != nil {
+		return customErr
+	}
+
+	select {
+	case s.mailSlots <- struct{}{}:
+		go func() {
+			defer func() { <-s.mailSlots }()
+			s.sendPasswordReset(context.WithoutCancel(ctx), request.Email)
+		}()
+	default:
+		log.Printf("user service: too many password reset emails pending, dropping request")
+	}
+	return nil
+}
+
+func (s *UserServiceImpl) sendPasswordReset(ctx context.Context, email string) {
+	var user model.User
+	err := s.UserRepository.FindByEmail(s.DB, &user, email)
+	if err != nil {
+		return
+	}
+
+	plainToken, userToken, err := s.reissueToken(user.Id, model.UserTokenPasswordReset, passwordResetTTL)
+	if err != nil {
+		log.Printf("user service: password reset token for user %d: %v", user.Id, err)
+		return
+	}
+	if userToken == nil {
+		return
+	}
+	link := s.AppUrl + "/reset-password?token=" + url.QueryEscape(plainToken)
+	err = s.NotificationService.SendAccountEmail(ctx, model.NotificationPasswordReset, user, link, userToken.ExpiresAt)
+	if err != nil {
+		log.Printf("user service: password reset email for user %d: %v", user.Id, err)
+	}
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword or
+// Create and signs the user out of every session. Following the emailed
+// link proves the user owns the address, so the address is verified and a
+// pending account activated as well.
+func (s *UserServiceImpl) ResetPassword(ctx context.Context, request *web.ResetPasswordRequest) *response.CustomError {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return response.BadRequestError(err.Error())
+	}
+
+	var policyErr error
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := s.consumeToken(tx, model.UserTokenPasswordReset, request.Token)
+		if err != nil {
+			return err
+		}
+		var user model.User
+		err = s.UserRepository.FindById(tx, &user, userToken.UserId)
+		if err != nil {
+			return err
+		}
+		// Rolling back leaves the token unused, so the user can try another
+		// password with the same link.
+		policyErr = s.PasswordPolicy.Check(request.Password, user.Email)
+		if policyErr != nil {
+			return policyErr
+		}
+		passwordHash, err := s.PasswordHasher.Hash(request.Password)
+		if err != nil {
+			return err
+		}
+
+		userBefore := user
+		now := time.Now()
+		user.Password = passwordHash
+		user.SessionVersion++
+		user.UpdatedAt = now
+		if user.Status == model.UserStatusPending {
+			user.Status = model.UserStatusActive
+		}
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &now
+		}
+		err = s.UserRepository.ResetPassword(tx, &user)
+		if err != nil {
+			return err
+		}
+		err = s.UserTokenRepository.InvalidateByUserId(tx, user.Id, model.UserTokenPasswordReset, now)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &userBefore, &user)
+	})
+	if policyErr != nil {
+		return response.BadRequestError(policyErr.Error())
+	}
+	if err != nil {
+		return response.BadRequestError("Invalid or expired reset token!")
+	}
+	return nil
+}
+
+// ValidateSession rejects tokens of deleted users and tokens issued before
+// the user's sessions were last invalidated.
+func (s *UserServiceImpl) ValidateSession(ctx context.Context, userId int, sessionVersion int) *response.CustomError {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return response.UnauthorizedError("User not found")
+	}
+	if user.SessionVersion != sessionVersion {
+		return response.UnauthorizedError("Session has been revoked")
+	}
+	// Expired accounts keep their sessions; see UpdateStatus.
+	if user.Status == model.UserStatusSuspended {
+		return response.UnauthorizedError("Account has been suspended")
+	}
+	return nil
+}
+
 func (s *UserServiceImpl) Login(ctx context.Context, request *web.LoginUserRequest) (*web.LoginUserResponse, *response.CustomError) {
 	err := s.Validate.Struct(request)
 	if err != nil {
 		return nil, response.BadRequestError(err.Error())
 	}
 
-	var user model.User
+	now := time.Now()
+	if customErr := checkLoginThrottle(s.DB, s.LoginThrottleRepository, now, request.Email, request.IpAddress); customErr != nil {
+		return nil, customErr
+	}
 
+	// Unknown emails and wrong passwords cost the same hash verification
+	// and get the same answer, so the response does not reveal which
+	// emails have accounts.
+	var user model.User
 	err = s.UserRepository.FindByEmail(s.DB, &user, request.Email)
 	if err != nil {
-		return nil, response.RepositoryError(err.Error())
+		s.PasswordHasher.Verify(s.dummyPasswordHash, request.Password)
+		recordLoginFailure(ctx, s.DB, s.LoginThrottleRepository, s.AuditLogRepository, now, request.Email, request.IpAddress, 0, loginFailurePassword)
+		return nil, response.UnauthorizedError("Invalid email or password")
 	}
 
-	err = helper.CheckPasswordHash(user.Password, request.Password)
+	err = s.PasswordHasher.Verify(user.Password, request.Password)
 	if err != nil {
-		return nil, response.GeneralError(err.Error())
+		recordLoginFailure(ctx, s.DB, s.LoginThrottleRepository, s.AuditLogRepository, now, request.Email, request.IpAddress, user.Id, loginFailurePassword)
+		return nil, response.UnauthorizedError("Invalid email or password")
 	}
+	if user.Status == model.UserStatusPending {
+		return nil, response.ForbiddenError("Email address has not been verified!")
+	}
+	s.rehashPassword(user, request.Password)
+
+	return s.startSession(user)
+}
 
-	token, err := token.GenerateJwtToken(strconv.Itoa(user.Id))
+// LoginTwoFactor finishes a login started with Login by checking a TOTP or
+// recovery code against the challenge. Wrong codes count as failed logins,
+// and the challenge stays usable until it expires or a code is accepted.
+func (s *UserServiceImpl) LoginTwoFactor(ctx context.Context, request *web.LoginTwoFactorRequest) (*web.LoginUserResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
 	if err != nil {
-		return nil, response.GeneralError(err.Error())
+		return nil, response.BadRequestError(err.Error())
 	}
-	loginResponse := web.LoginUserResponse{
-		Name:  user.Name,
-		Email: user.Email,
-		Token: token,
+
+	now := time.Now()
+	var challenge model.UserToken
+	err = s.UserTokenRepository.FindByHash(s.DB, &challenge, model.UserTokenLoginChallenge, token.HashOpaqueToken(request.ChallengeToken))
+	if err != nil || challenge.UsedAt != nil || now.After(challenge.ExpiresAt) {
+		return nil, response.UnauthorizedError("Login challenge is invalid or has expired")
 	}
 
-	return &loginResponse, nil
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, challenge.UserId)
+	if err != nil {
+		return nil, response.UnauthorizedError("Login challenge is invalid or has expired")
+	}
+	if user.Status == model.UserStatusSuspended {
+		return nil, response.ForbiddenError("Account has been suspended")
+	}
+	if customErr := checkLoginThrottle(s.DB, s.LoginThrottleRepository, now, user.Email, request.IpAddress); customErr != nil {
+		return nil, customErr
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.TwoFactorService.VerifyCode(ctx, tx, user, request.Code)
+		if err != nil {
+			return err
+		}
+		return s.UserTokenRepository.Consume(tx, challenge.Id, now)
+	})
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		recordLoginFailure(ctx, s.DB, s.LoginThrottleRepository, s.AuditLogRepository, now, user.Email, request.IpAddress, user.Id, loginFailureTwoFactor)
+		return nil, response.UnauthorizedError(err.Error())
+	}
+	if err != nil {
+		return nil, response.UnauthorizedError("Login challenge is invalid or has expired")
+	}
+
+	return s.completeLogin(user)
+}
+
+// LoginExternal logs in the user an identity provider vouched for. The
+// identity is looked up by issuer and subject; the first time it is seen it
+// is linked to the account with the same email, or to a new account, but
+// only if the provider has verified that email.
+func (s *UserServiceImpl) LoginExternal(ctx context.Context, claims *oidc.Claims) (*web.LoginUserResponse, *response.CustomError) {
+	now := time.Now()
+	var identity model.UserIdentity
+	err := s.UserIdentityRepository.FindBySubject(s.DB, &identity, claims.Issuer, claims.Subject)
+	if err == nil {
+		var user model.User
+		err = s.UserRepository.FindById(s.DB, &user, identity.UserId)
+		if err != nil {
+			return nil, response.ForbiddenError("Account has been deleted")
+		}
+		identity.Email = claims.Email
+		identity.LastLoginAt = &now
+		if err := s.UserIdentityRepository.UpdateLogin(s.DB, &identity); err != nil {
+			log.Printf("user service: record login of identity %d: %v", identity.Id, err)
+		}
+		return s.startSession(user)
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, response.ForbiddenError("Identity provider did not supply a verified email address")
+	}
+	user, err := s.linkIdentity(ctx, claims, now)
+	if errors.Is(err, errStaffIdentityLink) {
+		return nil, response.ForbiddenError("Staff accounts cannot be signed in to with an external identity")
+	}
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return s.startSession(*user)
 }
 
 func (s *UserServiceImpl) UpdateUserOwn(ctx context.Context, request *web.UpdateUserRequest) (*web.UserResponse, *response.CustomError) {
@@ -111,24 +505,258 @@ func (s *UserServiceImpl) UpdateUserOwn(ctx context.Context, request *web.Update
 		return nil, response.NotFoundError(err.Error())
 	}
 
-	password, err := helper.HashPassword(request.Password)
+	return s.update(ctx, user, request)
+}
+
+func (s *UserServiceImpl) PatchUserOwn(ctx context.Context, userId int, patch []byte) (*web.UserResponse, *response.CustomError) {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	document, err := json.Marshal(web.UpdateUserRequest{
+		Id:      user.Id,
+		Name:    user.Name,
+		Phone:   user.Phone,
+		Address: user.Address,
+	})
 	if err != nil {
 		return nil, response.GeneralError(err.Error())
 	}
 
+	patched, keys, err := helper.MergePatch(document, patch)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var request web.UpdateUserRequest
+	err = json.Unmarshal(patched, &request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	request.Id = user.Id
+
+	fields, err := helper.StructFieldNames(request, keys)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	err = s.Validate.StructPartial(request, fields...)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	return s.update(ctx, user, &request)
+}
+
+func (s *UserServiceImpl) update(ctx context.Context, user model.User, request *web.UpdateUserRequest) (*web.UserResponse, *response.CustomError) {
+	before := user
 	user.Name = request.Name
-	user.Password = password
+	user.Phone = request.Phone
+	user.Address = request.Address
+	user.UpdatedAt = time.Now()
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.Update(tx, &user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, &user)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	userResponse := toUserResponse(user)
+	return &userResponse, nil
+}
+
+func (s *UserServiceImpl) FindOwn(ctx context.Context, userId int) (*web.UserResponse, *response.CustomError) {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	userResponse := toUserResponse(user)
+	return &userResponse, nil
+}
+
+// ChangePassword replaces the password of a signed-in user who knows the
+// current one. Wrong guesses count as failed logins. Every session is
+// signed out, so the response carries a token for the caller to continue
+// with.
+func (s *UserServiceImpl) ChangePassword(ctx context.Context, request *web.ChangePasswordRequest) (*web.ChangePasswordResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, request.UserId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+	if customErr := s.checkCurrentPassword(ctx, user, request.CurrentPassword, request.IpAddress); customErr != nil {
+		return nil, customErr
+	}
+	err = s.PasswordPolicy.Check(request.NewPassword, user.Email)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+	passwordHash, err := s.PasswordHasher.Hash(request.NewPassword)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
 
-	err = s.UserRepository.Update(s.DB, &user)
+	before := user
+	now := time.Now()
+	user.Password = passwordHash
+	user.SessionVersion++
+	user.UpdatedAt = now
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.ResetPassword(tx, &user)
+		if err != nil {
+			return err
+		}
+		err = s.UserTokenRepository.InvalidateByUserId(tx, user.Id, model.UserTokenPasswordReset, now)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, &user)
+	})
 	if err != nil {
 		return nil, response.RepositoryError(err.Error())
 	}
 
-	userResponse := web.UserResponse{
-		Email: user.Email,
-		Name:  user.Name,
+	jwtToken, err := token.GenerateJwtToken(strconv.Itoa(user.Id), user.Role, user.SessionVersion)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+	return &web.ChangePasswordResponse{Token: jwtToken}, nil
+}
+
+// ChangeEmail starts moving the account to a new address. The account keeps
+// its current address until the link mailed to the new one is followed.
+func (s *UserServiceImpl) ChangeEmail(ctx context.Context, request *web.ChangeEmailRequest) *response.CustomError {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return response.BadRequestError(err.Error())
+	}
+
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, request.UserId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+	if customErr := s.checkCurrentPassword(ctx, user, request.CurrentPassword, request.IpAddress); customErr != nil {
+		return customErr
+	}
+	if strings.EqualFold(request.Email, user.Email) {
+		return response.BadRequestError("New email address is the same as the current one")
+	}
+	var count int64
+	err = s.UserRepository.CountByEmail(s.DB, &count, request.Email)
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+	if count > 0 {
+		return response.ConflictError("Email address is already in use")
 	}
 
+	plainToken, userToken, err := s.reissueToken(user.Id, model.UserTokenEmailChange, emailChangeTTL)
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+	if userToken == nil {
+		return response.TooManyRequestsError(nil, "A confirmation email was sent recently, try again later")
+	}
+
+	before := user
+	user.PendingEmail = request.Email
+	user.UpdatedAt = time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.RequestEmailChange(tx, &user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, &user)
+	})
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	recipient := user
+	recipient.Email = user.PendingEmail
+	link := s.AppUrl + "/api/email/change/confirm?token=" + url.QueryEscape(plainToken)
+	err = s.NotificationService.SendAccountEmail(ctx, model.NotificationChangeEmail, recipient, link, userToken.ExpiresAt)
+	if err != nil {
+		log.Printf("user service: email change confirmation for user %d: %v", user.Id, err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange switches the account to its pending address and lets
+// the old address know.
+func (s *UserServiceImpl) ConfirmEmailChange(ctx context.Context, request *web.VerifyEmailRequest) (*web.UserResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var user, before model.User
+	var conflict bool
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		userToken, err := s.consumeToken(tx, model.UserTokenEmailChange, request.Token)
+		if err != nil {
+			return err
+		}
+		err = s.UserRepository.FindById(tx, &user, userToken.UserId)
+		if err != nil {
+			return err
+		}
+		if user.PendingEmail == "" {
+			return errors.New("no email change pending")
+		}
+		// The address may have been taken since the change was requested.
+		var count int64
+		err = s.UserRepository.CountByEmail(tx, &count, user.PendingEmail)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			conflict = true
+			return errors.New("email address is already in use")
+		}
+
+		before = user
+		now := time.Now()
+		user.Email = user.PendingEmail
+		user.PendingEmail = ""
+		if user.Status == model.UserStatusPending {
+			user.Status = model.UserStatusActive
+		}
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		err = s.UserRepository.ChangeEmail(tx, &user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, &user)
+	})
+	if conflict {
+		return nil, response.ConflictError("Email address is already in use")
+	}
+	if err != nil {
+		return nil, response.BadRequestError("Invalid or expired confirmation token!")
+	}
+
+	err = s.NotificationService.SendAccountEmail(ctx, model.NotificationEmailChanged, before, "", time.Time{})
+	if err != nil {
+		log.Printf("user service: email changed notice for user %d: %v", user.Id, err)
+	}
+
+	userResponse := toUserResponse(user)
 	return &userResponse, nil
 }
 
@@ -139,7 +767,662 @@ func (s *UserServiceImpl) Delete(ctx context.Context, userId int) *response.Cust
 		return response.RepositoryError(err.Error())
 	}
 
-	s.UserRepository.Delete(s.DB, userId)
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.Delete(tx, userId)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionDelete, model.AuditEntityUser, userId, &user, nil)
+	})
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	return nil
+}
+
+func (s *UserServiceImpl) FindDeleted(ctx context.Context) ([]web.UserResponse, *response.CustomError) {
+	var users []model.User
+	err := s.UserRepository.FindDeleted(s.DB, &users)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	userResponses := []web.UserResponse{}
+	for _, user := range users {
+		userResponses = append(userResponses, toUserResponse(user))
+	}
+
+	return userResponses, nil
+}
+
+func (s *UserServiceImpl) Restore(ctx context.Context, userId int) (*web.UserResponse, *response.CustomError) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.Restore(tx, userId)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionRestore, model.AuditEntityUser, userId, nil, nil)
+	})
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	var user model.User
+	err = s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	userResponse := toUserResponse(user)
+	return &userResponse, nil
+}
+
+func (s *UserServiceImpl) Purge(ctx context.Context, deletedBefore time.Time) (int64, *response.CustomError) {
+	var purged int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purged, err = s.UserRepository.Purge(tx, deletedBefore)
+		if err != nil || purged == 0 {
+			return err
+		}
+		after := map[string]any{"Count": purged, "DeletedBefore": deletedBefore}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionPurge, model.AuditEntityUser, 0, nil, after)
+	})
+	if err != nil {
+		return 0, response.RepositoryError(err.Error())
+	}
+
+	return purged, nil
+}
+
+// Unlock lifts a login lockout on the user's account and clears its failed
+// attempts. Lockouts on client IPs are left to expire.
+func (s *UserServiceImpl) Unlock(ctx context.Context, userId int) *response.CustomError {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+
+	accountKey, _ := loginThrottleKeys(user.Email, "")
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.LoginThrottleRepository.Delete(tx, accountKey)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUnlock, model.AuditEntityUser, userId, nil, nil)
+	})
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+
+	return nil
+}
+
+func (s *UserServiceImpl) FindAll(ctx context.Context, filter *web.UserFilter) (*web.PageResponse, *response.CustomError) {
+	err := s.Validate.Struct(filter)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	page := filter.PageRequest.Normalize()
+
+	var users []model.User
+	var total int64
+	err = s.UserRepository.FindAll(s.DB, &users, &total, &model.UserFilter{
+		Query:      strings.TrimSpace(filter.Query),
+		Status:     filter.Status,
+		Role:       filter.Role,
+		PatronType: filter.PatronType,
+		Limit:      page.Limit,
+		Offset:     page.Offset(),
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	userResponses := []web.UserResponse{}
+	for _, user := range users {
+		userResponses = append(userResponses, toUserResponse(user))
+	}
+
+	return &web.PageResponse{
+		Items: userResponses,
+		Page:  page.Page,
+		Limit: page.Limit,
+		Total: total,
+	}, nil
+}
+
+// FindById returns the account with the patron's loan counts and unpaid
+// fines.
+func (s *UserServiceImpl) FindById(ctx context.Context, userId int) (*web.UserDetailResponse, *response.CustomError) {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	detail := web.UserDetailResponse{
+		UserResponse: toUserResponse(user),
+		StatusReason: user.StatusReason,
+		CreatedAt:    user.CreatedAt,
+	}
+	err = s.BorrowingRepository.CountActiveByUserId(s.DB, &detail.ActiveLoans, user.Id)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+	err = s.BorrowingRepository.CountOverdueByUserId(s.DB, &detail.OverdueLoans, user.Id, time.Now(), 0)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+	err = s.FineRepository.SumOutstandingByUserId(s.DB, &detail.OutstandingCents, user.Id)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	return &detail, nil
+}
+
+// Create opens an active patron account for someone signing up at the desk.
+// The account has no password until the patron chooses one with the link
+// mailed to them, which also verifies their address.
+func (s *UserServiceImpl) Create(ctx context.Context, request *web.CreateUserRequest) (*web.UserResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	if customErr := s.checkEmail(request.Email); customErr != nil {
+		return nil, customErr
+	}
+	if customErr := s.checkCardNumber(request.CardNumber, 0); customErr != nil {
+		return nil, customErr
+	}
+
+	patronType := request.PatronType
+	if patronType == "" {
+		patronType = model.PatronTypeStandard
+	}
+	now := time.Now()
+	user := model.User{
+		Name:       request.Name,
+		Email:      request.Email,
+		Role:       model.RoleMember,
+		PatronType: patronType,
+		CardNumber: cardNumber(request.CardNumber),
+		Phone:      request.Phone,
+		Address:    request.Address,
+		Status:     model.UserStatusActive,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	var plainToken string
+	var userToken model.UserToken
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.Save(tx, &user)
+		if err != nil {
+			return err
+		}
+		plainToken, userToken, err = s.issueToken(tx, user.Id, model.UserTokenPasswordReset, accountSetupTTL)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityUser, user.Id, nil, &user)
+		if err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, s.OutboxRepository, model.EventUserRegistered, model.AuditEntityUser, user.Id, toUserResponse(user))
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	link := s.AppUrl + "/reset-password?token=" + url.QueryEscape(plainToken)
+	err = s.NotificationService.SendAccountEmail(ctx, model.NotificationAccountCreated, user, link, userToken.ExpiresAt)
+	if err != nil {
+		log.Printf("user service: account created email for user %d: %v", user.Id, err)
+	}
+
+	userResponse := toUserResponse(user)
+	return &userResponse, nil
+}
+
+func (s *UserServiceImpl) UpdateAccount(ctx context.Context, userId int, request *web.UpdateAccountRequest) (*web.UserResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	user, customErr := s.findPatron(userId)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if customErr := s.checkCardNumber(request.CardNumber, user.Id); customErr != nil {
+		return nil, customErr
+	}
+
+	before := *user
+	user.Name = request.Name
+	user.PatronType = request.PatronType
+	user.CardNumber = cardNumber(request.CardNumber)
+	user.Phone = request.Phone
+	user.Address = request.Address
+	user.UpdatedAt = time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.UpdateAccount(tx, user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, user)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	userResponse := toUserResponse(*user)
+	return &userResponse, nil
+}
+
+// UpdateStatus suspends, expires or reactivates a patron account.
+// Suspending signs the patron out everywhere. Expiring deliberately does
+// not: an expired patron keeps self-service access and is only refused new
+// loans and renewals by the circulation policy. A pending account can only
+// be activated by verifying its email.
+func (s *UserServiceImpl) UpdateStatus(ctx context.Context, userId int, request *web.UserStatusRequest) (*web.UserResponse, *response.CustomError) {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	user, customErr := s.findPatron(userId)
+	if customErr != nil {
+		return nil, customErr
+	}
+	if user.Status == request.Status {
+		return nil, response.ConflictError("Account is already " + user.Status)
+	}
+	if user.Status == model.UserStatusPending && request.Status == model.UserStatusActive {
+		return nil, response.PreconditionFailedError("Account has not verified its email address")
+	}
+
+	before := *user
+	user.Status = request.Status
+	user.StatusReason = request.Reason
+	if user.Status == model.UserStatusSuspended {
+		user.SessionVersion++
+	}
+	user.UpdatedAt = time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.UpdateStatus(tx, user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, user)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	userResponse := toUserResponse(*user)
+	return &userResponse, nil
+}
+
+// BootstrapAdmin creates the first admin account, so a fresh database does
+// not need a seeded one with a known password. It does nothing once any
+// admin exists and reports whether it created one.
+func (s *UserServiceImpl) BootstrapAdmin(ctx context.Context, name string, email string, password string) (bool, error) {
+	var admins int64
+	err := s.UserRepository.CountByRole(s.DB, &admins, model.RoleAdmin)
+	if err != nil || admins > 0 {
+		return false, err
+	}
+
+	err = s.PasswordPolicy.Check(password, email)
+	if err != nil {
+		return false, err
+	}
+	passwordHash, err := s.PasswordHasher.Hash(password)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	user := model.User{
+		Name:            name,
+		Email:           email,
+		Password:        passwordHash,
+		Role:            model.RoleAdmin,
+		PatronType:      model.PatronTypeStandard,
+		Status:          model.UserStatusActive,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.Save(tx, &user)
+		if err != nil {
+			return err
+		}
+		err = s.UserRepository.VerifyEmail(tx, &user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityUser, user.Id, nil, &user)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// findPatron loads a member account for staff to manage. Staff accounts
+// are left out so librarians cannot lock out each other or admins.
+func (s *UserServiceImpl) findPatron(userId int) (*model.User, *response.CustomError) {
+	var user model.User
+	err := s.UserRepository.FindById(s.DB, &user, userId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+	if user.Role != model.RoleMember {
+		return nil, response.ForbiddenError("Only patron accounts can be managed here")
+	}
+	return &user, nil
+}
+
+// checkEmail rejects an address another account holds. A deleted account
+// keeps its address until it is purged, so staff are pointed at restoring
+// it instead.
+func (s *UserServiceImpl) checkEmail(email string) *response.CustomError {
+	var users []model.User
+	err := s.UserRepository.FindByEmailWithDeleted(s.DB, &users, email)
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	if users[0].DeletedAt != nil {
+		return response.ConflictError(fmt.Sprintf("Email address belongs to deleted user %d; restore it with POST /api/admin/users/%d/restore", users[0].Id, users[0].Id))
+	}
+	return response.ConflictError("Email address is already in use")
+}
+
+// checkCardNumber rejects a card number already issued to another user.
+func (s *UserServiceImpl) checkCardNumber(number string, userId int) *response.CustomError {
+	if number == "" {
+		return nil
+	}
+	var count int64
+	err := s.UserRepository.CountByCardNumber(s.DB, &count, number, userId)
+	if err != nil {
+		return response.RepositoryError(err.Error())
+	}
+	if count > 0 {
+		return response.ConflictError("Card number is already in use")
+	}
+	return nil
+}
+
+// cardNumber stores a blank card number as NULL, since the column is unique.
+func cardNumber(number string) *string {
+	if number == "" {
+		return nil
+	}
+	return &number
+}
+
+// issueToken stores a new single-use token for the user and returns the
+// plain token to send them.
+func (s *UserServiceImpl) issueToken(tx *gorm.DB, userId int, purpose string, ttl time.Duration) (string, model.UserToken, error) {
+	plainToken, tokenHash := token.NewOpaqueToken()
+	now := time.Now()
+	userToken := model.UserToken{
+		UserId:    userId,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	err := s.UserTokenRepository.Save(tx, &userToken)
+	return plainToken, userToken, err
+}
+
+// rehashPassword upgrades the stored hash after a successful login when it
+// was made with an older algorithm or cost. Failures only postpone the
+// upgrade to the next login.
+func (s *UserServiceImpl) rehashPassword(user model.User, plainPassword string) {
+	if !s.PasswordHasher.NeedsRehash(user.Password) {
+		return
+	}
+	passwordHash, err := s.PasswordHasher.Hash(plainPassword)
+	if err == nil {
+		err = s.UserRepository.UpdatePasswordHash(s.DB, user.Id, passwordHash)
+	}
+	if err != nil {
+		log.Printf("user service: rehash password for user %d: %v", user.Id, err)
+	}
+}
+
+// reissueToken replaces the user's outstanding tokens for purpose with a new
+// one. It returns a nil token without error when the user has been sent one
+// too recently.
+func (s *UserServiceImpl) reissueToken(userId int, purpose string, ttl time.Duration) (string, *model.UserToken, error) {
+	now := time.Now()
+	var recent, hourly int64
+	err := s.UserTokenRepository.CountSince(s.DB, &recent, userId, purpose, now.Add(-tokenMailInterval))
+	if err != nil {
+		return "", nil, err
+	}
+	err = s.UserTokenRepository.CountSince(s.DB, &hourly, userId, purpose, now.Add(-time.Hour))
+	if err != nil {
+		return "", nil, err
+	}
+	if recent > 0 || hourly >= tokenMailLimit {
+		return "", nil, nil
+	}
+
+	var plainToken string
+	var userToken model.UserToken
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserTokenRepository.InvalidateByUserId(tx, userId, purpose, now)
+		if err != nil {
+			return err
+		}
+		plainToken, userToken, err = s.issueToken(tx, userId, purpose, ttl)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return plainToken, &userToken, nil
+}
+
+// consumeToken checks a token mailed to a user and marks it used.
+func (s *UserServiceImpl) consumeToken(tx *gorm.DB, purpose string, plainToken string) (*model.UserToken, error) {
+	var userToken model.UserToken
+	err := s.UserTokenRepository.FindByHash(tx, &userToken, purpose, token.HashOpaqueToken(plainToken))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if userToken.UsedAt != nil || now.After(userToken.ExpiresAt) {
+		return nil, errors.New("token expired")
+	}
+	err = s.UserTokenRepository.Consume(tx, userToken.Id, now)
+	if err != nil {
+		return nil, err
+	}
+	return &userToken, nil
+}
+
+// startSession answers a successful first login step: with a challenge for
+// a two-factor code when the account has it enabled, and with the JWT
+// otherwise.
+func (s *UserServiceImpl) startSession(user model.User) (*web.LoginUserResponse, *response.CustomError) {
+	if user.Status == model.UserStatusSuspended {
+		return nil, response.ForbiddenError("Account has been suspended")
+	}
+	if user.TotpEnabledAt != nil {
+		var challengeToken string
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			challengeToken, _, err = s.issueToken(tx, user.Id, model.UserTokenLoginChallenge, loginChallengeTTL)
+			return err
+		})
+		if err != nil {
+			return nil, response.RepositoryError(err.Error())
+		}
+		return &web.LoginUserResponse{
+			Name:              user.Name,
+			Email:             user.Email,
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		}, nil
+	}
+
+	return s.completeLogin(user)
+}
 
+// errStaffIdentityLink is returned by linkIdentity for staff accounts.
+var errStaffIdentityLink = errors.New("external identities are not linked to staff accounts")
+
+// linkIdentity records a new external identity against the account with its
+// email, creating the account if there is none. Since the provider verified
+// the email, a pending account is verified along the way. Only member
+// accounts are linked: a verified email at the provider must not be enough
+// to take over a librarian or admin account.
+func (s *UserServiceImpl) linkIdentity(ctx context.Context, claims *oidc.Claims, now time.Time) (*model.User, error) {
+	var user model.User
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := s.UserRepository.FindByEmail(tx, &user, claims.Email)
+		if err != nil {
+			name := claims.Name
+			if name == "" {
+				name, _, _ = strings.Cut(claims.Email, "@")
+			}
+			user = model.User{
+				Name:            name,
+				Email:           claims.Email,
+				Role:            model.RoleMember,
+				PatronType:      model.PatronTypeStandard,
+				Status:          model.UserStatusActive,
+				EmailVerifiedAt: &now,
+				CreatedAt:       now,
+				UpdatedAt:       now,
+			}
+			err = s.UserRepository.Save(tx, &user)
+			if err != nil {
+				return err
+			}
+			err = s.UserRepository.VerifyEmail(tx, &user)
+			if err != nil {
+				return err
+			}
+			err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionCreate, model.AuditEntityUser, user.Id, nil, &user)
+			if err != nil {
+				return err
+			}
+			err = recordEvent(ctx, tx, s.OutboxRepository, model.EventUserRegistered, model.AuditEntityUser, user.Id, toUserResponse(user))
+			if err != nil {
+				return err
+			}
+		} else if user.Role != model.RoleMember {
+			return errStaffIdentityLink
+		} else if user.Status == model.UserStatusPending {
+			before := user
+			user.Status = model.UserStatusActive
+			user.EmailVerifiedAt = &now
+			user.UpdatedAt = now
+			err = s.UserRepository.VerifyEmail(tx, &user)
+			if err != nil {
+				return err
+			}
+			err = recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionUpdate, model.AuditEntityUser, user.Id, &before, &user)
+			if err != nil {
+				return err
+			}
+		}
+
+		identity := model.UserIdentity{
+			UserId:      user.Id,
+			Issuer:      claims.Issuer,
+			Subject:     claims.Subject,
+			Email:       claims.Email,
+			CreatedAt:   now,
+			LastLoginAt: &now,
+		}
+		err = s.UserIdentityRepository.Save(tx, &identity)
+		if err != nil {
+			return err
+		}
+		after := map[string]any{"Issuer": claims.Issuer, "Subject": claims.Subject}
+		return recordAudit(ctx, tx, s.AuditLogRepository, model.AuditActionLinkIdentity, model.AuditEntityUser, user.Id, nil, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// completeLogin clears the account's failed logins and issues the JWT.
+func (s *UserServiceImpl) completeLogin(user model.User) (*web.LoginUserResponse, *response.CustomError) {
+	accountKey, _ := loginThrottleKeys(user.Email, "")
+	if err := s.LoginThrottleRepository.Delete(s.DB, accountKey); err != nil {
+		log.Printf("user service: reset login failures for user %d: %v", user.Id, err)
+	}
+
+	token, err := token.GenerateJwtToken(strconv.Itoa(user.Id), user.Role, user.SessionVersion)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+	loginResponse := web.LoginUserResponse{
+		Name:                        user.Name,
+		Email:                       user.Email,
+		Token:                       token,
+		TwoFactorEnrollmentRequired: s.TwoFactorService.Required(user.Role),
+	}
+
+	return &loginResponse, nil
+}
+
+// checkCurrentPassword guards account changes with the user's password,
+// under the same throttling as logins.
+func (s *UserServiceImpl) checkCurrentPassword(ctx context.Context, user model.User, currentPassword string, ipAddress string) *response.CustomError {
+	now := time.Now()
+	if customErr := checkLoginThrottle(s.DB, s.LoginThrottleRepository, now, user.Email, ipAddress); customErr != nil {
+		return customErr
+	}
+	if s.PasswordHasher.Verify(user.Password, currentPassword) != nil {
+		recordLoginFailure(ctx, s.DB, s.LoginThrottleRepository, s.AuditLogRepository, now, user.Email, ipAddress, user.Id, loginFailureCurrentPassword)
+		return response.UnauthorizedError("Current password is incorrect")
+	}
 	return nil
 }
+
+func (s *UserServiceImpl) sendVerification(ctx context.Context, user model.User, plainToken string, userToken model.UserToken) {
+	link := s.AppUrl + "/api/email/verify?token=" + url.QueryEscape(plainToken)
+	err := s.NotificationService.SendAccountEmail(ctx, model.NotificationVerifyEmail, user, link, userToken.ExpiresAt)
+	if err != nil {
+		log.Printf("user service: verification email for user %d: %v", user.Id, err)
+	}
+}
+
+func toUserResponse(user model.User) web.UserResponse {
+	return web.UserResponse{
+		Id:               user.Id,
+		Name:             user.Name,
+		Email:            user.Email,
+		Role:             user.Role,
+		PatronType:       user.PatronType,
+		CardNumber:       user.CardNumber,
+		Phone:            user.Phone,
+		Address:          user.Address,
+		Status:           user.Status,
+		EmailVerifiedAt:  user.EmailVerifiedAt,
+		PendingEmail:     user.PendingEmail,
+		TwoFactorEnabled: user.TotpEnabledAt != nil,
+		DeletedAt:        user.DeletedAt,
+	}
+}
@@ -0,0 +1,493 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kukuh/go-gin-library-project/app/model"
+	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/web"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/response"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const (
+	maxWebhookAttempts     = 8
+	webhookBackoffBase     = 30 * time.Second
+	webhookBackoffMax      = 6 * time.Hour
+	webhookBatchSize       = 100
+	webhookResponseMaxSize = 1 << 10
+)
+
+type WebhookService interface {
+	Create(ctx context.Context, request *web.WebhookSubscriptionRequest) (*web.WebhookSubscriptionResponse, *response.CustomError)
+	Update(ctx context.Context, request *web.WebhookSubscriptionRequest) (*web.WebhookSubscriptionResponse, *response.CustomError)
+	Delete(ctx context.Context, subscriptionId int) *response.CustomError
+	FindAll(ctx context.Context) ([]web.WebhookSubscriptionResponse, *response.CustomError)
+	FindDeliveries(ctx context.Context, subscriptionId int, filter *web.WebhookDeliveryFilter) (*web.PageResponse, *response.CustomError)
+	Redeliver(ctx context.Context, subscriptionId int, deliveryId int) (*web.WebhookDeliveryResponse, *response.CustomError)
+	DeliverPending(ctx context.Context, now time.Time) (int, *response.CustomError)
+	HandleEvent(ctx context.Context, tx *gorm.DB, event model.OutboxEvent) error
+}
+
+type WebhookServiceImpl struct {
+	WebhookRepository repository.WebhookRepository
+	Client            *http.Client
+	DB                *gorm.DB
+	Validate          *validator.Validate
+}
+
+func NewWebhookService(webhookRepository repository.WebhookRepository, DB *gorm.DB, validate *validator.Validate) WebhookService {
+	return &WebhookServiceImpl{
+		WebhookRepository: webhookRepository,
+		Client:            newWebhookClient(),
+		DB:                DB,
+		Validate:          validate,
+	}
+}
+
+func (s *WebhookServiceImpl) Create(ctx context.Context, request *web.WebhookSubscriptionRequest) (*web.WebhookSubscriptionResponse, *response.CustomError) {
+	err := s.validate(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	events, err := json.Marshal(request.Events)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		secret = helper.RandomHex(32)
+	}
+
+	subscription := model.WebhookSubscription{
+		Url:       request.Url,
+		Secret:    secret,
+		Events:    string(events),
+		Active:    request.Active == nil || *request.Active,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.WebhookRepository.SaveSubscription(tx, &subscription)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	// The secret is only ever shown once, when the subscription is created.
+	subscriptionResponse := toWebhookSubscriptionResponse(subscription)
+	subscriptionResponse.Secret = subscription.Secret
+
+	return &subscriptionResponse, nil
+}
+
+func (s *WebhookServiceImpl) Update(ctx context.Context, request *web.WebhookSubscriptionRequest) (*web.WebhookSubscriptionResponse, *response.CustomError) {
+	err := s.validate(request)
+	if err != nil {
+		return nil, response.BadRequestError(err.Error())
+	}
+
+	var subscription model.WebhookSubscription
+	err = s.WebhookRepository.FindSubscription(s.DB, &subscription, request.Id)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	events, err := json.Marshal(request.Events)
+	if err != nil {
+		return nil, response.GeneralError(err.Error())
+	}
+
+	subscription.Url = request.Url
+	subscription.Events = string(events)
+	subscription.UpdatedAt = time.Now()
+	if request.Secret != "" {
+		subscription.Secret = request.Secret
+	}
+	if request.Active != nil {
+		subscription.Active = *request.Active
+	}
+
+	err = s.WebhookRepository.UpdateSubscription(s.DB, &subscription)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	subscriptionResponse := toWebhookSubscriptionResponse(subscription)
+	return &subscriptionResponse, nil
+}
+
+func (s *WebhookServiceImpl) Delete(ctx context.Context, subscriptionId int) *response.CustomError {
+	err := s.WebhookRepository.DeleteSubscription(s.DB, subscriptionId)
+	if err != nil {
+		return response.NotFoundError(err.Error())
+	}
+	return nil
+}
+
+func (s *WebhookServiceImpl) FindAll(ctx context.Context) ([]web.WebhookSubscriptionResponse, *response.CustomError) {
+	var subscriptions []model.WebhookSubscription
+	err := s.WebhookRepository.FindAllSubscriptions(s.DB, &subscriptions)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	subscriptionResponses := []web.WebhookSubscriptionResponse{}
+	for _, subscription := range subscriptions {
+		subscriptionResponses = append(subscriptionResponses, toWebhookSubscriptionResponse(subscription))
+	}
+
+	return subscriptionResponses, nil
+}
+
+func (s *WebhookServiceImpl) FindDeliveries(ctx context.Context, subscriptionId int, filter *web.WebhookDeliveryFilter) (*web.PageResponse, *response.CustomError) {
+	page := filter.PageRequest.Normalize()
+
+	var deliveries []model.WebhookDelivery
+	var total int64
+	err := s.WebhookRepository.FindDeliveries(s.DB, &deliveries, &total, &model.WebhookDeliveryFilter{
+		SubscriptionId: subscriptionId,
+		Status:         filter.Status,
+		Limit:          page.Limit,
+		Offset:         page.Offset(),
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	deliveryResponses := []web.WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		deliveryResponses = append(deliveryResponses, toWebhookDeliveryResponse(delivery))
+	}
+
+	return &web.PageResponse{
+		Items: deliveryResponses,
+		Page:  page.Page,
+		Limit: page.Limit,
+		Total: total,
+	}, nil
+}
+
+// Redeliver sends an earlier delivery's payload again as a new delivery, so
+// the original attempt stays in the log. The first attempt is made straight
+// away; if it fails the usual retry schedule takes over.
+func (s *WebhookServiceImpl) Redeliver(ctx context.Context, subscriptionId int, deliveryId int) (*web.WebhookDeliveryResponse, *response.CustomError) {
+	var original model.WebhookDelivery
+	err := s.WebhookRepository.FindDelivery(s.DB, &original, deliveryId)
+	if err != nil || original.SubscriptionId != subscriptionId {
+		return nil, response.NotFoundError("Delivery not found!")
+	}
+
+	var subscription model.WebhookSubscription
+	err = s.WebhookRepository.FindSubscription(s.DB, &subscription, subscriptionId)
+	if err != nil {
+		return nil, response.NotFoundError(err.Error())
+	}
+
+	now := time.Now()
+	delivery := model.WebhookDelivery{
+		SubscriptionId: original.SubscriptionId,
+		EventId:        original.EventId,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         model.WebhookDeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.WebhookRepository.SaveDelivery(tx, &delivery)
+	})
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	err = s.deliver(ctx, subscription, &delivery, now)
+	if err != nil {
+		return nil, response.RepositoryError(err.Error())
+	}
+
+	deliveryResponse := toWebhookDeliveryResponse(delivery)
+	return &deliveryResponse, nil
+}
+
+// DeliverPending attempts every delivery whose next attempt is due.
+func (s *WebhookServiceImpl) DeliverPending(ctx context.Context, now time.Time) (int, *response.CustomError) {
+	var deliveries []model.WebhookDelivery
+	err := s.WebhookRepository.FindDueDeliveries(s.DB, &deliveries, now, webhookBatchSize)
+	if err != nil {
+		return 0, response.RepositoryError(err.Error())
+	}
+
+	subscriptions := map[int]*model.WebhookSubscription{}
+	attempted := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		subscription, ok := subscriptions[delivery.SubscriptionId]
+		if !ok {
+			subscription = new(model.WebhookSubscription)
+			err := s.WebhookRepository.FindSubscription(s.DB, subscription, delivery.SubscriptionId)
+			if err != nil {
+				return attempted, response.RepositoryError(err.Error())
+			}
+			subscriptions[delivery.SubscriptionId] = subscription
+		}
+
+		if !subscription.Active {
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.Error = "subscription is inactive"
+			err = s.WebhookRepository.UpdateDelivery(s.DB, delivery)
+		} else {
+			err = s.deliver(ctx, *subscription, delivery, now)
+			attempted++
+		}
+		if err != nil {
+			return attempted, response.RepositoryError(err.Error())
+		}
+	}
+
+	return attempted, nil
+}
+
+// deliver makes one attempt and records the outcome. Failures are retried
+// with exponential backoff until maxWebhookAttempts is reached. Only an
+// error saving the outcome is returned.
+func (s *WebhookServiceImpl) deliver(ctx context.Context, subscription model.WebhookSubscription, delivery *model.WebhookDelivery, now time.Time) error {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	err := s.post(ctx, subscription, delivery)
+	if err == nil && (delivery.ResponseStatus < 200 || delivery.ResponseStatus > 299) {
+		err = fmt.Errorf("unexpected status %d", delivery.ResponseStatus)
+	}
+
+	switch {
+	case err == nil:
+		deliveredAt := time.Now()
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &deliveredAt
+	case delivery.Attempts >= maxWebhookAttempts:
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.Status = model.WebhookDeliveryPending
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+	}
+
+	return s.WebhookRepository.UpdateDelivery(s.DB, delivery)
+}
+
+func (s *WebhookServiceImpl) post(ctx context.Context, subscription model.WebhookSubscription, delivery *model.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Id", delivery.EventId)
+	request.Header.Set("X-Webhook-Event", delivery.Event)
+	request.Header.Set("X-Webhook-Delivery", strconv.Itoa(delivery.Id))
+	request.Header.Set("X-Webhook-Signature", fmt.Sprintf("t=%d,v1=%s", timestamp, signWebhook(subscription.Secret, timestamp, body)))
+
+	resp, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxSize))
+	delivery.ResponseStatus = resp.StatusCode
+	delivery.ResponseBody = string(responseBody)
+	return nil
+}
+
+func (s *WebhookServiceImpl) validate(request *web.WebhookSubscriptionRequest) error {
+	err := s.Validate.Struct(request)
+	if err != nil {
+		return err
+	}
+	target, err := url.Parse(request.Url)
+	if err != nil {
+		return err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("url must use http or https")
+	}
+	for _, event := range request.Events {
+		if event != model.WebhookEventAll && !slices.Contains(model.EventTypes, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
+
+// newWebhookClient returns the client deliveries are POSTed with. Subscriber
+// URLs are supplied by API users, so the client refuses to connect to
+// loopback, private and link-local addresses (checked on the resolved
+// address, which also covers DNS names pointing inward) and never follows
+// redirects.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicWebhookAddr(addrPort.Addr()) {
+				return fmt.Errorf("webhook address %s is not allowed", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func publicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified()
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>". Receivers
+// recompute it with their copy of the secret and should reject stale
+// timestamps to prevent replays.
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBackoffBase
+	for i := 1; i < attempts && backoff < webhookBackoffMax; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookBackoffMax)
+}
+
+// HandleEvent queues a delivery of event to every active subscription that
+// wants it. Deliveries are written with tx so they are queued exactly once
+// per event.
+func (s *WebhookServiceImpl) HandleEvent(ctx context.Context, tx *gorm.DB, event model.OutboxEvent) error {
+	var subscriptions []model.WebhookSubscription
+	err := s.WebhookRepository.FindActiveSubscriptions(tx, &subscriptions)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		var events []string
+		err := json.Unmarshal([]byte(subscription.Events), &events)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(events, event.Type) && !slices.Contains(events, model.WebhookEventAll) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(web.WebhookPayload{
+				Id:        event.EventId,
+				Event:     event.Type,
+				CreatedAt: event.CreatedAt,
+				Data:      json.RawMessage(event.Payload),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.WebhookRepository.SaveDelivery(tx, &model.WebhookDelivery{
+			SubscriptionId: subscription.Id,
+			EventId:        event.EventId,
+			Event:          event.Type,
+			Payload:        string(payload),
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toWebhookSubscriptionResponse(subscription model.WebhookSubscription) web.WebhookSubscriptionResponse {
+	var events []string
+	_ = json.Unmarshal([]byte(subscription.Events), &events)
+
+	return web.WebhookSubscriptionResponse{
+		Id:        subscription.Id,
+		Url:       subscription.Url,
+		Events:    events,
+		Active:    subscription.Active,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery model.WebhookDelivery) web.WebhookDeliveryResponse {
+	return web.WebhookDeliveryResponse{
+		Id:             delivery.Id,
+		SubscriptionId: delivery.SubscriptionId,
+		EventId:        delivery.EventId,
+		Event:          delivery.Event,
+		Payload:        json.RawMessage(delivery.Payload),
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		ResponseBody:   delivery.ResponseBody,
+		Error:          delivery.Error,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{maxWebhookAttempts, 64 * time.Minute},
+		{10, 256 * time.Minute},
+		// 512 minutes is over the cap.
+		{11, 6 * time.Hour},
+		{1000, 6 * time.Hour},
+	}
+	for _, test := range tests {
+		if got := webhookBackoff(test.attempts); got != test.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// Expected values computed independently with
+	// printf '<timestamp>.<body>' | openssl dgst -sha256 -hmac <secret>.
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{"body", "whsec_test", 1700000000, `{"event":"book.created"}`, "a277cbc57685ce7c1bc59ceedd3ed971ad32f5096604eb1be15bea0df54ec629"},
+		{"empty body", "whsec_test", 1700000000, ``, "5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := signWebhook(test.secret, test.timestamp, []byte(test.body)); got != test.want {
+				t.Errorf("signWebhook = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSignWebhookCoversEveryInput(t *testing.T) {
+	base := signWebhook("whsec_test", 1700000000, []byte(`{"a":1}`))
+	for name, signature := range map[string]string{
+		"secret":    signWebhook("whsec_other", 1700000000, []byte(`{"a":1}`)),
+		"timestamp": signWebhook("whsec_test", 1700000001, []byte(`{"a":1}`)),
+		"body":      signWebhook("whsec_test", 1700000000, []byte(`{"a":2}`)),
+	} {
+		if signature == base {
+			t.Errorf("changing the %s does not change the signature", name)
+		}
+	}
+}
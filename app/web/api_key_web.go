@@ -0,0 +1,23 @@
+package web
+
+import "time"
+
+type ApiKeyCreateRequest struct {
+	Name      string     `validate:"required,max=255" json:"name"`
+	Scopes    []string   `validate:"required,min=1,dive,oneof=catalog:write users:write loans:write reports:read audit:read" json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ApiKeyResponse struct {
+	Id int `json:"id"`
+	// Key is only set in the response to creating the key.
+	Key        string     `json:"key,omitempty"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLogFilter struct {
+	ActorId  int       `form:"actor_id"`
+	Action   string    `form:"action"`
+	Entity   string    `form:"entity"`
+	EntityId int       `form:"entity_id"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	PageRequest
+}
+
+type AuditLogResponse struct {
+	Id        int             `json:"id"`
+	ActorId   *int            `json:"actor_id"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityId  int             `json:"entity_id"`
+	Changes   json.RawMessage `json:"changes"`
+	RequestId string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
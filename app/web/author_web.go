@@ -0,0 +1,18 @@
+package web
+
+type AuthorCreate struct {
+	Name string `validate:"required" json:"name"`
+	Bio  string `json:"bio"`
+}
+
+type AuthorUpdate struct {
+	Id   int    `validate:"required" json:"id"`
+	Name string `validate:"required" json:"name"`
+	Bio  string `json:"bio"`
+}
+
+type AuthorResponse struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Bio  string `json:"bio"`
+}
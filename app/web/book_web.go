@@ -1,27 +1,66 @@
 package web
 
+import "time"
+
 type BookCreate struct {
-	Title           string `validate:"required" json:"title"`
-	Author          string `validate:"required" json:"author"`
-	Isbn            string `validate:"required" json:"isbn"`
-	PublicationYear int    `validate:"required" json:"publication_year"`
-	Quantity        int    `validate:"required" json:"quantity"`
+	Title                string `validate:"required" json:"title"`
+	AuthorIds            []int  `validate:"required,min=1" json:"author_ids"`
+	PublisherIds         []int  `json:"publisher_ids"`
+	SubjectIds           []int  `json:"subject_ids"`
+	Isbn                 string `validate:"required" json:"isbn"`
+	Category             string `json:"category"`
+	PublicationYear      int    `validate:"required" json:"publication_year"`
+	Quantity             int    `validate:"required" json:"quantity"`
+	ReplacementCostCents int64  `validate:"gte=0" json:"replacement_cost_cents"`
 }
 
 type BookUpdate struct {
-	Id              int    `validate:"required" json:"id"`
-	Title           string `validate:"required" json:"title"`
-	Author          string `validate:"required" json:"author"`
-	Isbn            string `validate:"required" json:"isbn"`
-	PublicationYear int    `validate:"required" json:"publication_year"`
-	Quantity        int    `validate:"required" json:"quantity"`
+	Id                   int    `validate:"required" json:"id"`
+	Title                string `validate:"required" json:"title"`
+	AuthorIds            []int  `validate:"required,min=1" json:"author_ids"`
+	PublisherIds         []int  `json:"publisher_ids"`
+	SubjectIds           []int  `json:"subject_ids"`
+	Isbn                 string `validate:"required" json:"isbn"`
+	Category             string `json:"category"`
+	PublicationYear      int    `validate:"required" json:"publication_year"`
+	Quantity             int    `validate:"required" json:"quantity"`
+	ReplacementCostCents int64  `validate:"gte=0" json:"replacement_cost_cents"`
+	Version              int    `json:"-"`
+}
+
+type BookFilter struct {
+	AuthorId    int `form:"author_id"`
+	PublisherId int `form:"publisher_id"`
+	SubjectId   int `form:"subject_id"`
 }
 
 type BookResponse struct {
-	Id              int    `json:"id"`
-	Title           string `json:"name"`
-	Author          string `json:"author"`
-	Isbn            string `json:"isbn"`
-	PublicationYear int    `json:"publication_year"`
-	Quantity        int    `json:"quantity"`
+	Id                   int                 `json:"id"`
+	Title                string              `json:"name"`
+	Authors              []AuthorResponse    `json:"authors"`
+	Publishers           []PublisherResponse `json:"publishers"`
+	Subjects             []SubjectResponse   `json:"subjects"`
+	Isbn                 string              `json:"isbn"`
+	Category             string              `json:"category"`
+	PublicationYear      int                 `json:"publication_year"`
+	Quantity             int                 `json:"quantity"`
+	ReplacementCostCents int64               `json:"replacement_cost_cents"`
+	CoverUrl             string              `json:"cover_url"`
+	Version              int                 `json:"version"`
+	DeletedAt            *time.Time          `json:"deleted_at,omitempty"`
+}
+
+// BookEventData is the payload of book events.
+type BookEventData struct {
+	Id                   int    `json:"id"`
+	Title                string `json:"name"`
+	Isbn                 string `json:"isbn"`
+	Category             string `json:"category"`
+	PublicationYear      int    `json:"publication_year"`
+	Quantity             int    `json:"quantity"`
+	ReplacementCostCents int64  `json:"replacement_cost_cents"`
+	Version              int    `json:"version"`
+	AuthorIds            []int  `json:"author_ids"`
+	PublisherIds         []int  `json:"publisher_ids"`
+	SubjectIds           []int  `json:"subject_ids"`
 }
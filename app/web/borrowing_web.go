@@ -5,25 +5,59 @@ import "time"
 type BorrowingCreateRequest struct {
 	BookId  int    `validate:"required" json:"book_id"`
 	UserId  int    `json:"user_id"`
-	DueDate string `validate:"required" json:"due_date"`
+	DueDate string `json:"due_date"`
+}
+
+type BorrowingStatusRequest struct {
+	Status string `validate:"required,oneof=returned lost claimed_returned damaged" json:"status"`
+}
+
+type BorrowingFilter struct {
+	Status string `validate:"omitempty,oneof=borrowed overdue returned late_returned lost claimed_returned damaged" form:"status"`
+	Active *bool  `form:"active"`
+	Expand string `form:"expand"`
+	PageRequest
+}
+
+type LostItemFilter struct {
+	Status string `validate:"omitempty,oneof=lost damaged" form:"status"`
+	PageRequest
+}
+
+type LostItemResponse struct {
+	BorrowingId      int        `json:"borrowing_id"`
+	Status           string     `json:"status"`
+	BookId           int        `json:"book_id"`
+	BookTitle        string     `json:"book_title"`
+	BookIsbn         string     `json:"book_isbn"`
+	UserId           int        `json:"user_id"`
+	UserName         string     `json:"user_name"`
+	DueDate          time.Time  `json:"due_date"`
+	ReturnDate       *time.Time `json:"return_date"`
+	ChargedCents     int64      `json:"charged_cents"`
+	OutstandingCents int64      `json:"outstanding_cents"`
 }
 
 type BorrowingResponse struct {
-	Id         int       `json:"id"`
-	UserId     int       `json:"user_id"`
-	BookId     int       `json:"book_id"`
-	BorrowDate time.Time `json:"borrow_date"`
-	DueDate    time.Time `json:"due_date"`
-	Status     string    `json:"status"`
-	ReturnDate time.Time `json:"return_date"`
-}
-
-type BorrowingFindResponse struct {
-	Id         int       `json:"id"`
-	User       string    `json:"user"`
-	Book       string    `json:"book"`
-	BorrowDate time.Time `json:"borrow_date"`
-	DueDate    time.Time `json:"due_date"`
-	Status     string    `json:"status"`
-	ReturnDate time.Time `json:"return_date"`
+	Id           int                    `json:"id"`
+	UserId       int                    `json:"user_id"`
+	BookId       int                    `json:"book_id"`
+	BorrowDate   time.Time              `json:"borrow_date"`
+	DueDate      time.Time              `json:"due_date"`
+	Status       string                 `json:"status"`
+	ReturnDate   *time.Time             `json:"return_date"`
+	RenewalCount int                    `json:"renewal_count"`
+	Book         *BorrowingBookResponse `json:"book,omitempty"`
+	User         *BorrowingUserResponse `json:"user,omitempty"`
+}
+
+type BorrowingBookResponse struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+	Isbn  string `json:"isbn"`
+}
+
+type BorrowingUserResponse struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
 }
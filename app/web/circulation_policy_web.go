@@ -0,0 +1,29 @@
+package web
+
+type CirculationPolicyRequest struct {
+	PatronType     string `validate:"required" json:"patron_type"`
+	BookCategory   string `validate:"required" json:"book_category"`
+	LoanDays       int    `validate:"required,min=1" json:"loan_days"`
+	MaxLoanDays    int    `validate:"required,gtefield=LoanDays" json:"max_loan_days"`
+	MaxLoans       int    `validate:"min=0" json:"max_loans"`
+	MaxRenewals    int    `validate:"min=0" json:"max_renewals"`
+	MaxFineCents   int64  `validate:"min=0" json:"max_fine_cents"`
+	BlockOnOverdue bool   `json:"block_on_overdue"`
+}
+
+type CirculationPolicyResponse struct {
+	Id             int    `json:"id"`
+	PatronType     string `json:"patron_type"`
+	BookCategory   string `json:"book_category"`
+	LoanDays       int    `json:"loan_days"`
+	MaxLoanDays    int    `json:"max_loan_days"`
+	MaxLoans       int    `json:"max_loans"`
+	MaxRenewals    int    `json:"max_renewals"`
+	MaxFineCents   int64  `json:"max_fine_cents"`
+	BlockOnOverdue bool   `json:"block_on_overdue"`
+}
+
+type PolicyViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,28 @@
+package web
+
+import "time"
+
+type JobResponse struct {
+	Name      string    `json:"name"`
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+}
+
+type JobRunFilter struct {
+	Job    string `form:"job"`
+	Status string `form:"status"`
+	PageRequest
+}
+
+type JobRunResponse struct {
+	Id          int        `json:"id"`
+	Job         string     `json:"job"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	TriggeredBy string     `json:"triggered_by"`
+	ActorId     *int       `json:"actor_id"`
+	Status      string     `json:"status"`
+	Instance    string     `json:"instance"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
@@ -0,0 +1,20 @@
+package web
+
+type NotificationPreferenceRequest struct {
+	UserId       int  `json:"-"`
+	EmailEnabled bool `json:"email_enabled"`
+	LoanCreated  bool `json:"loan_created"`
+	DueSoon      bool `json:"due_soon"`
+	Overdue      bool `json:"overdue"`
+	Returned     bool `json:"returned"`
+	DueSoonDays  int  `validate:"min=1,max=30" json:"due_soon_days"`
+}
+
+type NotificationPreferenceResponse struct {
+	EmailEnabled bool `json:"email_enabled"`
+	LoanCreated  bool `json:"loan_created"`
+	DueSoon      bool `json:"due_soon"`
+	Overdue      bool `json:"overdue"`
+	Returned     bool `json:"returned"`
+	DueSoonDays  int  `json:"due_soon_days"`
+}
@@ -0,0 +1,16 @@
+package web
+
+// OidcCallbackRequest is the identity provider's redirect back to us.
+// StateCookie is the state we set in the browser when the login started.
+type OidcCallbackRequest struct {
+	Code             string `validate:"required" form:"code"`
+	State            string `validate:"required" form:"state"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+	StateCookie      string `form:"-"`
+}
+
+type OidcStartResponse struct {
+	AuthorizationUrl string `json:"authorization_url"`
+	State            string `json:"state"`
+}
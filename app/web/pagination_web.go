@@ -0,0 +1,35 @@
+package web
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+type PageRequest struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+func (p PageRequest) Normalize() PageRequest {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = defaultPageLimit
+	}
+	if p.Limit > maxPageLimit {
+		p.Limit = maxPageLimit
+	}
+	return p
+}
+
+func (p PageRequest) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+type PageResponse struct {
+	Items any   `json:"items"`
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Total int64 `json:"total"`
+}
@@ -0,0 +1,15 @@
+package web
+
+type PublisherCreate struct {
+	Name string `validate:"required" json:"name"`
+}
+
+type PublisherUpdate struct {
+	Id   int    `validate:"required" json:"id"`
+	Name string `validate:"required" json:"name"`
+}
+
+type PublisherResponse struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
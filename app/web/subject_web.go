@@ -0,0 +1,15 @@
+package web
+
+type SubjectCreate struct {
+	Name string `validate:"required" json:"name"`
+}
+
+type SubjectUpdate struct {
+	Id   int    `validate:"required" json:"id"`
+	Name string `validate:"required" json:"name"`
+}
+
+type SubjectResponse struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
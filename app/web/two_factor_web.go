@@ -0,0 +1,31 @@
+package web
+
+type TwoFactorCodeRequest struct {
+	Code      string `validate:"required" json:"code"`
+	IpAddress string `json:"-"`
+}
+
+// TwoFactorDisableRequest needs the password as well as a code, so a stolen
+// session is not enough to turn two-factor authentication off.
+type TwoFactorDisableRequest struct {
+	CurrentPassword string `validate:"required" json:"current_password"`
+	Code            string `validate:"required" json:"code"`
+	IpAddress       string `json:"-"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+// TwoFactorConfirmResponse returns a fresh token because confirming the
+// enrollment signs out the sessions started without two-factor
+// authentication.
+type TwoFactorConfirmResponse struct {
+	Token         string   `json:"token"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
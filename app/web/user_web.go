@@ -1,30 +1,136 @@
 package web
 
+import "time"
+
 type Register struct {
 	Name     string `validate:"required" json:"name"`
-	Email    string `validate:"required" json:"email"`
+	Email    string `validate:"required,email" json:"email"`
+	Password string `validate:"required" json:"password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `validate:"required" form:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `validate:"required,email" json:"email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email     string `validate:"required,email" json:"email"`
+	IpAddress string `json:"-"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `validate:"required" json:"token"`
 	Password string `validate:"required" json:"password"`
 }
 
 type UserResponse struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Id               int        `json:"id"`
+	Name             string     `json:"name"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	PatronType       string     `json:"patron_type"`
+	CardNumber       *string    `json:"card_number"`
+	Phone            string     `json:"phone"`
+	Address          string     `json:"address"`
+	Status           string     `json:"status"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`
+	PendingEmail     string     `json:"pending_email,omitempty"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+// UserDetailResponse is what staff see of an account: the user along with
+// their standing at the library.
+type UserDetailResponse struct {
+	UserResponse
+	StatusReason     string    `json:"status_reason"`
+	ActiveLoans      int64     `json:"active_loans"`
+	OverdueLoans     int64     `json:"overdue_loans"`
+	OutstandingCents int64     `json:"outstanding_cents"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type UserFilter struct {
+	Query      string `form:"q"`
+	Status     string `validate:"omitempty,oneof=pending active suspended expired" form:"status"`
+	Role       string `validate:"omitempty,oneof=member librarian admin" form:"role"`
+	PatronType string `form:"patron_type"`
+	PageRequest
+}
+
+// CreateUserRequest opens a patron account on the patron's behalf. The
+// patron is mailed a link to choose a password.
+type CreateUserRequest struct {
+	Name       string `validate:"required" json:"name"`
+	Email      string `validate:"required,email" json:"email"`
+	PatronType string `validate:"omitempty,max=50" json:"patron_type"`
+	CardNumber string `validate:"omitempty,alphanum,max=32" json:"card_number"`
+	Phone      string `validate:"omitempty,e164" json:"phone"`
+	Address    string `validate:"omitempty,max=500" json:"address"`
+}
+
+type UpdateAccountRequest struct {
+	Name       string `validate:"required" json:"name"`
+	PatronType string `validate:"required,max=50" json:"patron_type"`
+	CardNumber string `validate:"omitempty,alphanum,max=32" json:"card_number"`
+	Phone      string `validate:"omitempty,e164" json:"phone"`
+	Address    string `validate:"omitempty,max=500" json:"address"`
+}
+
+type UserStatusRequest struct {
+	Status string `validate:"required,oneof=active suspended expired" json:"status"`
+	Reason string `validate:"max=255" json:"reason"`
 }
 
 type UpdateUserRequest struct {
-	Id       int    `validate:"required" json:"id"`
-	Name     string `validate:"required" json:"name"`
-	Password string `validate:"required" json:"password"`
+	Id      int    `validate:"required" json:"id"`
+	Name    string `validate:"required" json:"name"`
+	Phone   string `validate:"omitempty,e164" json:"phone"`
+	Address string `validate:"omitempty,max=500" json:"address"`
+}
+
+type ChangePasswordRequest struct {
+	UserId          int    `json:"-"`
+	CurrentPassword string `validate:"required" json:"current_password"`
+	NewPassword     string `validate:"required" json:"new_password"`
+	IpAddress       string `json:"-"`
+}
+
+// ChangePasswordResponse carries a new token, since changing the password
+// signs out every session including the current one.
+type ChangePasswordResponse struct {
+	Token string `json:"token"`
+}
+
+type ChangeEmailRequest struct {
+	UserId          int    `json:"-"`
+	Email           string `validate:"required,email" json:"email"`
+	CurrentPassword string `validate:"required" json:"current_password"`
+	IpAddress       string `json:"-"`
 }
 
 type LoginUserRequest struct {
-	Email    string `validate:"required" json:"email"`
-	Password string `validate:"required" json:"password"`
+	Email     string `validate:"required" json:"email"`
+	Password  string `validate:"required" json:"password"`
+	IpAddress string `json:"-"`
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `validate:"required" json:"challenge_token"`
+	Code           string `validate:"required" json:"code"`
+	IpAddress      string `json:"-"`
 }
 
+// LoginUserResponse carries either the JWT or, when the account has
+// two-factor authentication enabled, the challenge to answer with a code.
 type LoginUserResponse struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Token string `json:"token"`
+	Name                        string `json:"name"`
+	Email                       string `json:"email"`
+	Token                       string `json:"token,omitempty"`
+	TwoFactorRequired           bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken              string `json:"challenge_token,omitempty"`
+	TwoFactorEnrollmentRequired bool   `json:"two_factor_enrollment_required,omitempty"`
 }
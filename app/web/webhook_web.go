@@ -0,0 +1,53 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookSubscriptionRequest struct {
+	Id     int      `json:"-"`
+	Url    string   `validate:"required,http_url" json:"url"`
+	Secret string   `validate:"omitempty,min=16" json:"secret"`
+	Events []string `validate:"required,min=1" json:"events"`
+	Active *bool    `json:"active"`
+}
+
+type WebhookSubscriptionResponse struct {
+	Id        int       `json:"id"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryFilter struct {
+	Status string `form:"status"`
+	PageRequest
+}
+
+type WebhookDeliveryResponse struct {
+	Id             int             `json:"id"`
+	SubscriptionId int             `json:"subscription_id"`
+	EventId        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status"`
+	ResponseBody   string          `json:"response_body"`
+	Error          string          `json:"error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+// WebhookPayload is the body POSTed to subscribers.
+type WebhookPayload struct {
+	Id        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
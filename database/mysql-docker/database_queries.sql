@@ -1,5 +1,28 @@
 -- ---
+DROP TABLE IF EXISTS job_locks;
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS user_tokens;
+DROP TABLE IF EXISTS login_throttles;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS oidc_logins;
+DROP TABLE IF EXISTS outbox_handled;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS fines;
 DROP TABLE IF EXISTS borrowings;
+DROP TABLE IF EXISTS circulation_policies;
+DROP TABLE IF EXISTS book_subjects;
+DROP TABLE IF EXISTS book_publishers;
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS subjects;
+DROP TABLE IF EXISTS publishers;
+DROP TABLE IF EXISTS authors;
 DROP TABLE IF EXISTS books;
 DROP TABLE IF EXISTS users;
 -- ---
@@ -8,14 +31,78 @@ DROP TABLE IF EXISTS users;
 CREATE TABLE books (
     id INT PRIMARY KEY AUTO_INCREMENT,
     title VARCHAR(255) NOT NULL,
-    author VARCHAR(255) NOT NULL,
     isbn VARCHAR(13) UNIQUE NOT NULL,
+    category VARCHAR(50) NOT NULL DEFAULT 'general',
     publication_year INT,
     quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0),
+    replacement_cost_cents BIGINT NOT NULL DEFAULT 0,
+    cover_key VARCHAR(255) NOT NULL DEFAULT '',
+    version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    INDEX idx_books_deleted_at (deleted_at)
+);
+-- ---
+-- Table: authors
+-- ---
+CREATE TABLE authors (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    bio TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+-- ---
+-- Table: publishers
+-- ---
+CREATE TABLE publishers (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
 -- ---
+-- Table: subjects
+-- ---
+CREATE TABLE subjects (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+-- ---
+-- Table: book_authors
+-- ---
+CREATE TABLE book_authors (
+    book_id INT NOT NULL,
+    author_id INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE RESTRICT
+);
+-- ---
+-- Table: book_publishers
+-- ---
+CREATE TABLE book_publishers (
+    book_id INT NOT NULL,
+    publisher_id INT NOT NULL,
+    PRIMARY KEY (book_id, publisher_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (publisher_id) REFERENCES publishers(id) ON DELETE RESTRICT
+);
+-- ---
+-- Table: book_subjects
+-- ---
+CREATE TABLE book_subjects (
+    book_id INT NOT NULL,
+    subject_id INT NOT NULL,
+    PRIMARY KEY (book_id, subject_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE RESTRICT
+);
+-- ---
 -- Table: users
 -- ---
 CREATE TABLE users (
@@ -23,8 +110,97 @@ CREATE TABLE users (
     name VARCHAR(255) NOT NULL,
     email VARCHAR(255) UNIQUE NOT NULL,
     password VARCHAR(255),
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    patron_type VARCHAR(50) NOT NULL DEFAULT 'standard',
+    card_number VARCHAR(32) UNIQUE NULL,
+    phone VARCHAR(32) NOT NULL DEFAULT '',
+    address VARCHAR(500) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    status_reason VARCHAR(255) NOT NULL DEFAULT '',
+    email_verified_at TIMESTAMP NULL,
+    pending_email VARCHAR(255) NOT NULL DEFAULT '',
+    session_version INT NOT NULL DEFAULT 0,
+    totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+    totp_enabled_at TIMESTAMP NULL,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    INDEX idx_users_deleted_at (deleted_at),
+    INDEX idx_users_status (status)
+);
+-- ---
+-- Table: user_tokens
+-- Single-use tokens mailed to users. Only a keyed hash of the token is
+-- stored.
+-- ---
+CREATE TABLE user_tokens (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    purpose VARCHAR(50) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_user_tokens_hash (token_hash),
+    INDEX idx_user_tokens_user (user_id, purpose, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_recovery_codes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_user_recovery_codes (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE api_keys (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    prefix CHAR(8) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes JSON NOT NULL,
+    created_by INT NULL,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_api_keys_prefix (prefix),
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE user_identities (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP NULL,
+    UNIQUE KEY uq_user_identities_subject (issuer, subject),
+    INDEX idx_user_identities_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE oidc_logins (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    state_hash CHAR(64) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_oidc_logins_state (state_hash)
+);
+
+CREATE TABLE login_throttles (
+    throttle_key VARCHAR(300) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 -- ---
 -- Table: borrowings
@@ -35,47 +211,274 @@ CREATE TABLE borrowings (
     user_id INT NOT NULL,
     borrow_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     due_date TIMESTAMP,
-    status varchar(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'borrowed',
     return_date TIMESTAMP NULL,
+    renewal_count INT NOT NULL DEFAULT 0,
     FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE RESTRICT,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT,
+    CONSTRAINT chk_borrowings_status CHECK (status IN ('borrowed', 'overdue', 'returned', 'late_returned', 'lost', 'claimed_returned', 'damaged'))
+);
+-- ---
+-- Table: fines
+-- ---
+CREATE TABLE fines (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    borrowing_id INT NULL,
+    amount_cents BIGINT NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    paid_at TIMESTAMP NULL,
+    waived_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_fines_user (user_id, paid_at),
+    INDEX idx_fines_borrowing (borrowing_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT,
+    FOREIGN KEY (borrowing_id) REFERENCES borrowings(id) ON DELETE RESTRICT
+);
+-- ---
+-- Table: notifications
+-- Email notification log; the unique key keeps each notification from being
+-- sent twice for the same loan and due date.
+-- ---
+CREATE TABLE notifications (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    borrowing_id INT NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    due_date TIMESTAMP NOT NULL,
+    recipient VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    error TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP NULL,
+    UNIQUE KEY uq_notifications_event (borrowing_id, event, due_date),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT,
+    FOREIGN KEY (borrowing_id) REFERENCES borrowings(id) ON DELETE RESTRICT
+);
+-- ---
+-- Table: notification_preferences
+-- Patrons without a row get every notification with a 3 day reminder.
+-- ---
+CREATE TABLE notification_preferences (
+    user_id INT PRIMARY KEY,
+    email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    loan_created BOOLEAN NOT NULL DEFAULT TRUE,
+    due_soon BOOLEAN NOT NULL DEFAULT TRUE,
+    overdue BOOLEAN NOT NULL DEFAULT TRUE,
+    returned BOOLEAN NOT NULL DEFAULT TRUE,
+    due_soon_days INT NOT NULL DEFAULT 3,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- ---
+-- Table: circulation_policies
+-- patron_type and book_category accept '*' as a wildcard; the most specific
+-- match wins, with patron_type taking precedence over book_category.
+-- ---
+CREATE TABLE circulation_policies (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    patron_type VARCHAR(50) NOT NULL,
+    book_category VARCHAR(50) NOT NULL,
+    loan_days INT NOT NULL,
+    max_loan_days INT NOT NULL,
+    max_loans INT NOT NULL,
+    max_renewals INT NOT NULL DEFAULT 0,
+    max_fine_cents BIGINT NOT NULL DEFAULT 0,
+    block_on_overdue BOOLEAN NOT NULL DEFAULT TRUE,
+    UNIQUE KEY uq_circulation_policies (patron_type, book_category)
+);
+-- ---
+-- Table: audit_logs
+-- ---
+CREATE TABLE audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    actor_id INT NULL,
+    action VARCHAR(50) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INT NOT NULL DEFAULT 0,
+    changes JSON NOT NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_audit_logs_entity (entity, entity_id),
+    INDEX idx_audit_logs_actor (actor_id),
+    INDEX idx_audit_logs_created_at (created_at)
+);
+CREATE TRIGGER audit_logs_no_update BEFORE UPDATE ON audit_logs FOR EACH ROW
+SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_logs is append-only';
+CREATE TRIGGER audit_logs_no_delete BEFORE DELETE ON audit_logs FOR EACH ROW
+SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_logs is append-only';
+-- ---
+-- Table: outbox_events
+-- Domain events written in the same transaction as the change they describe.
+-- ---
+CREATE TABLE outbox_events (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    type VARCHAR(100) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id INT NOT NULL DEFAULT 0,
+    payload JSON NOT NULL,
+    actor_id INT NULL,
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(6) NOT NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    dispatched_at TIMESTAMP(6) NULL,
+    INDEX idx_outbox_events_due (status, next_attempt_at)
+);
+-- ---
+-- Table: outbox_handled
+-- Subscribers that have handled each event, so retries skip them.
+-- ---
+CREATE TABLE outbox_handled (
+    outbox_id BIGINT NOT NULL,
+    subscriber VARCHAR(100) NOT NULL,
+    handled_at TIMESTAMP(6) NOT NULL,
+    PRIMARY KEY (outbox_id, subscriber),
+    FOREIGN KEY (outbox_id) REFERENCES outbox_events(id) ON DELETE CASCADE
+);
+-- ---
+-- Table: webhook_subscriptions
+-- events is a JSON array of event types; '*' subscribes to all of them.
+-- ---
+CREATE TABLE webhook_subscriptions (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events JSON NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- ---
+-- Table: webhook_deliveries
+-- Delivery log; pending rows are retried with exponential backoff.
+-- ---
+CREATE TABLE webhook_deliveries (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    subscription_id INT NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    event VARCHAR(100) NOT NULL,
+    payload JSON NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(6) NOT NULL,
+    response_status INT NOT NULL DEFAULT 0,
+    response_body TEXT NULL,
+    error TEXT NULL,
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    delivered_at TIMESTAMP(6) NULL,
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at),
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+-- ---
+-- Table: job_runs
+-- One row per run of a scheduled job. The unique key lets only one replica
+-- claim a given scheduled time.
+-- ---
+CREATE TABLE job_runs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_name VARCHAR(100) NOT NULL,
+    scheduled_at TIMESTAMP(6) NOT NULL,
+    triggered_by VARCHAR(50) NOT NULL,
+    actor_id INT NULL,
+    status VARCHAR(50) NOT NULL,
+    instance VARCHAR(255) NOT NULL,
+    error TEXT NOT NULL,
+    started_at TIMESTAMP(6) NOT NULL,
+    finished_at TIMESTAMP(6) NULL,
+    UNIQUE KEY uq_job_runs_schedule (job_name, scheduled_at),
+    INDEX idx_job_runs_status (status)
+);
+-- ---
+-- Table: job_locks
+-- Lease held by the run currently executing a job.
+-- ---
+CREATE TABLE job_locks (
+    job_name VARCHAR(100) PRIMARY KEY,
+    locked_by VARCHAR(255) NOT NULL,
+    locked_until TIMESTAMP(6) NOT NULL
 );
 -- ---
 -- Dummy Data for books
 -- ---
-INSERT INTO books (title, author, isbn, publication_year, quantity)
+INSERT INTO books (title, isbn, publication_year, quantity)
 VALUES (
         'The Hitchhiker''s Guide to the Galaxy',
-        'Douglas Adams',
         '9780345391803',
         1979,
         5
     ),
     (
         '1984',
-        'George Orwell',
         '9780451524935',
         1949,
         7
     ),
     (
         'Pride and Prejudice',
-        'Jane Austen',
         '9780141439518',
         1813,
         3
     ),
     (
         'Sapiens: A Brief History of Humankind',
-        'Yuval Noah Harari',
         '9780062316097',
         2014,
         4
     ),
     (
         'To Kill a Mockingbird',
-        'Harper Lee',
         '9780446310789',
         1960,
         6
-    );
\ No newline at end of file
+    );
+-- ---
+-- Dummy Data for authors, publishers and subjects
+-- ---
+INSERT INTO authors (name)
+VALUES ('Douglas Adams'),
+    ('George Orwell'),
+    ('Jane Austen'),
+    ('Yuval Noah Harari'),
+    ('Harper Lee');
+INSERT INTO publishers (name)
+VALUES ('Del Rey'),
+    ('Signet Classics'),
+    ('Penguin Classics'),
+    ('Harper'),
+    ('Grand Central Publishing');
+INSERT INTO subjects (name)
+VALUES ('Science Fiction'),
+    ('Dystopian Fiction'),
+    ('Romance'),
+    ('History'),
+    ('Southern Gothic');
+INSERT INTO book_authors (book_id, author_id)
+VALUES (1, 1),
+    (2, 2),
+    (3, 3),
+    (4, 4),
+    (5, 5);
+INSERT INTO book_publishers (book_id, publisher_id)
+VALUES (1, 1),
+    (2, 2),
+    (3, 3),
+    (4, 4),
+    (5, 5);
+INSERT INTO book_subjects (book_id, subject_id)
+VALUES (1, 1),
+    (2, 1),
+    (2, 2),
+    (3, 3),
+    (4, 4),
+    (5, 5);
+-- ---
+-- Default circulation policies
+-- ---
+INSERT INTO circulation_policies (patron_type, book_category, loan_days, max_loan_days, max_loans, max_renewals, max_fine_cents, block_on_overdue)
+VALUES ('*', '*', 14, 28, 5, 2, 500, TRUE),
+    ('*', 'reference', 1, 1, 0, 0, 0, TRUE);
@@ -0,0 +1,45 @@
+-- ---
+-- Migration: books.author -> authors / book_authors
+-- ---
+-- Databases created before authors were catalogued keep each book's author
+-- as free text in books.author. This turns every distinct name into an
+-- author, links each book to it and drops the column. Run it once, before
+-- starting a release that no longer reads books.author. Books whose author
+-- is blank are left without a link and must be given one through the API.
+-- ---
+CREATE TABLE IF NOT EXISTS authors (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    bio TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id INT NOT NULL,
+    author_id INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id),
+    FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES authors(id) ON DELETE RESTRICT
+);
+
+START TRANSACTION;
+
+-- Names already catalogued are reused rather than created twice.
+INSERT INTO authors (name, created_at, updated_at)
+SELECT DISTINCT TRIM(b.author), NOW(), NOW()
+FROM books b
+WHERE TRIM(b.author) <> ''
+AND NOT EXISTS (SELECT 1 FROM authors a WHERE a.name = TRIM(b.author));
+
+-- authors.name is not unique, so a name catalogued more than once links to
+-- its oldest author.
+INSERT IGNORE INTO book_authors (book_id, author_id, position)
+SELECT b.id, a.id, 0
+FROM books b
+JOIN (SELECT name, MIN(id) AS id FROM authors GROUP BY name) a ON a.name = TRIM(b.author);
+
+COMMIT;
+
+ALTER TABLE books DROP COLUMN author;
@@ -0,0 +1,55 @@
+package helper
+
+import "context"
+
+type contextKey string
+
+const (
+	authIdKey    contextKey = "authId"
+	requestIdKey contextKey = "requestId"
+)
+
+func WithAuthId(ctx context.Context, authId string) context.Context {
+	return context.WithValue(ctx, authIdKey, authId)
+}
+
+func AuthId(ctx context.Context) string {
+	authId, _ := ctx.Value(authIdKey).(string)
+	return authId
+}
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey, requestId)
+}
+
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
+
+type afterCommitKey struct{}
+
+// AfterCommitHooks collects work registered with AfterCommit.
+type AfterCommitHooks struct {
+	Funcs []func(ctx context.Context) error
+}
+
+// WithAfterCommit returns a context whose AfterCommit calls are collected in
+// hooks instead of running straight away. The caller runs them once its
+// transaction has committed.
+func WithAfterCommit(ctx context.Context, hooks *AfterCommitHooks) context.Context {
+	return context.WithValue(ctx, afterCommitKey{}, hooks)
+}
+
+// AfterCommit defers fn until the surrounding transaction commits, so side
+// effects outside the database, such as sending mail, neither hold locks
+// nor happen for changes that are rolled back. Without a surrounding
+// collector fn runs immediately.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	hooks, ok := ctx.Value(afterCommitKey{}).(*AfterCommitHooks)
+	if !ok {
+		return fn(ctx)
+	}
+	hooks.Funcs = append(hooks.Funcs, fn)
+	return nil
+}
@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"image"
+	"image/color"
+)
+
+// Thumbnail scales src down to fit within maxWidth x maxHeight, keeping the
+// aspect ratio. Each destination pixel averages the source pixels it covers,
+// so downscaled covers do not alias. Images that already fit are returned as is.
+func Thumbnail(src image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth <= maxWidth && srcHeight <= maxHeight {
+		return src
+	}
+
+	width, height := maxWidth, srcHeight*maxWidth/srcWidth
+	if height > maxHeight {
+		width, height = srcWidth*maxHeight/srcHeight, maxHeight
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := bounds.Min.Y + (y+1)*srcHeight/height
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := bounds.Min.X + (x+1)*srcWidth/width
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
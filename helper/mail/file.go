@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"context"
+	"kukuh/go-gin-library-project/helper"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender writes each message as an .eml file instead of delivering it,
+// for local development.
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func NewFileSender(dir string, from string) Sender {
+	if dir == "" {
+		dir = filepath.Join("storage", "mail")
+	}
+	return &FileSender{
+		Dir:  dir,
+		From: from,
+	}
+}
+
+func (s *FileSender) Send(ctx context.Context, message Message) error {
+	data, err := build(s.From, message)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(s.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	name := time.Now().Format("20060102T150405") + "-" + helper.RandomHex(4) + ".eml"
+	return os.WriteFile(filepath.Join(s.Dir, name), data, 0o644)
+}
+
+// LogSender only logs that a message would have been sent. The body is left
+// out since account emails carry live verification and reset links.
+type LogSender struct {
+	From string
+}
+
+func NewLogSender(from string) Sender {
+	return &LogSender{
+		From: from,
+	}
+}
+
+func (s *LogSender) Send(ctx context.Context, message Message) error {
+	log.Printf("mail: from %s to %s: %s", s.From, message.To, message.Subject)
+	return nil
+}
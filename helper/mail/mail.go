@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"kukuh/go-gin-library-project/helper"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// build renders message as a multipart/alternative MIME document with a
+// plain text and an HTML part.
+func build(from string, message Message) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var data bytes.Buffer
+	fmt.Fprintf(&data, "From: %s\r\n", from)
+	fmt.Fprintf(&data, "To: %s\r\n", message.To)
+	fmt.Fprintf(&data, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&data, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&data, "Message-ID: <%s@library>\r\n", helper.RandomHex(16))
+	fmt.Fprintf(&data, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&data, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+	data.Write(body.Bytes())
+
+	return data.Bytes(), nil
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+)
+
+type SMTPSender struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+}
+
+// NewSMTPSender sends through the server at host:port, authenticating with
+// PLAIN auth when a username is given. net/smtp upgrades to STARTTLS when
+// the server offers it.
+func NewSMTPSender(host string, port string, username string, password string, from string) Sender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPSender{
+		Addr: net.JoinHostPort(host, port),
+		Auth: auth,
+		From: from,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message Message) error {
+	data, err := build(s.From, message)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{message.To}, data)
+}
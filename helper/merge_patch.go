@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// MergePatch applies an RFC 7396 JSON merge patch to original and returns the
+// patched document together with the top-level keys the patch touched.
+func MergePatch(original []byte, patch []byte) ([]byte, []string, error) {
+	var patchObject map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.UseNumber()
+	if err := decoder.Decode(&patchObject); err != nil || patchObject == nil {
+		return nil, nil, errors.New("merge patch must be a JSON object")
+	}
+
+	var target any
+	decoder = json.NewDecoder(bytes.NewReader(original))
+	decoder.UseNumber()
+	if err := decoder.Decode(&target); err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0, len(patchObject))
+	for key := range patchObject {
+		keys = append(keys, key)
+	}
+
+	patched, err := json.Marshal(mergeValue(target, patchObject))
+	if err != nil {
+		return nil, nil, err
+	}
+	return patched, keys, nil
+}
+
+func mergeValue(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergeValue(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+// StructFieldNames maps JSON keys to the Go field names of v, for use with
+// validator.StructPartial. Unknown keys are returned as an error.
+func StructFieldNames(v any, jsonKeys []string) ([]string, error) {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	fieldsByKey := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key != "" && key != "-" {
+			fieldsByKey[key] = field.Name
+		}
+	}
+
+	names := make([]string, 0, len(jsonKeys))
+	for _, key := range jsonKeys {
+		name, ok := fieldsByKey[key]
+		if !ok {
+			return nil, errors.New("unknown field: " + key)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
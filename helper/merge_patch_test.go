@@ -0,0 +1,119 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396 appendix A, with objects at the top level as
+	// MergePatch requires.
+	tests := []struct {
+		name     string
+		original string
+		patch    string
+		want     string
+		keys     []string
+	}{
+		{"replace value", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`, []string{"a"}},
+		{"add value", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`, []string{"b"}},
+		{"remove value", `{"a":"b"}`, `{"a":null}`, `{}`, []string{"a"}},
+		{"remove one of two", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`, []string{"a"}},
+		{"replace array", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`, []string{"a"}},
+		{"replace with array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`, []string{"a"}},
+		{"nested merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`, []string{"a"}},
+		{"arrays are replaced whole", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`, []string{"a"}},
+		{"nested null on missing", `{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`, []string{"a"}},
+		{"object onto scalar", `{"a":"foo"}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`, []string{"a"}},
+		{"empty patch", `{"a":"b"}`, `{}`, `{"a":"b"}`, []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patched, keys, err := MergePatch([]byte(test.original), []byte(test.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, patched, []byte(test.want)) {
+				t.Errorf("MergePatch = %s, want %s", patched, test.want)
+			}
+			slices.Sort(keys)
+			if !slices.Equal(keys, test.keys) {
+				t.Errorf("keys = %v, want %v", keys, test.keys)
+			}
+		})
+	}
+}
+
+func TestMergePatchKeepsNumberPrecision(t *testing.T) {
+	patched, _, err := MergePatch([]byte(`{"a":1,"b":9007199254740993}`), []byte(`{"a":9007199254740995}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":9007199254740995,"b":9007199254740993}`; string(patched) != want {
+		t.Errorf("MergePatch = %s, want %s", patched, want)
+	}
+}
+
+func TestMergePatchRejectsNonObjects(t *testing.T) {
+	for _, patch := range []string{`null`, `["a"]`, `"a"`, `1`, `{`, ``} {
+		_, _, err := MergePatch([]byte(`{"a":"b"}`), []byte(patch))
+		if err == nil {
+			t.Errorf("MergePatch accepted patch %q", patch)
+		}
+	}
+}
+
+func TestStructFieldNames(t *testing.T) {
+	type request struct {
+		Id       int    `json:"-"`
+		Title    string `json:"title"`
+		Isbn     string `json:"isbn,omitempty"`
+		Internal string
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		keys  []string
+		want  []string
+		fails bool
+	}{
+		{"known keys", request{}, []string{"title", "isbn"}, []string{"Title", "Isbn"}, false},
+		{"pointer", &request{}, []string{"isbn"}, []string{"Isbn"}, false},
+		{"no keys", request{}, nil, []string{}, false},
+		{"unknown key", request{}, []string{"title", "author"}, nil, true},
+		{"ignored field", request{}, []string{"-"}, nil, true},
+		{"untagged field", request{}, []string{"Internal"}, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names, err := StructFieldNames(test.value, test.keys)
+			if test.fails {
+				if err == nil {
+					t.Errorf("StructFieldNames = %v, want an error", names)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(names, test.want) {
+				t.Errorf("StructFieldNames = %v, want %v", names, test.want)
+			}
+		})
+	}
+}
+
+func jsonEqual(t *testing.T, a []byte, b []byte) bool {
+	t.Helper()
+	var x, y any
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,164 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	ErrMismatch         = errors.New("password does not match")
+	ErrUnknownHash      = errors.New("unknown password hash format")
+	ErrInvalidHash      = errors.New("invalid password hash")
+	ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+)
+
+// Params selects the algorithm new hashes are made with and its cost.
+type Params struct {
+	Algorithm string
+	// Argon2Memory is in KiB.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
+}
+
+// DefaultParams follow the OWASP recommendation for argon2id.
+var DefaultParams = Params{
+	Algorithm:         AlgorithmArgon2id,
+	Argon2Memory:      64 * 1024,
+	Argon2Iterations:  3,
+	Argon2Parallelism: 2,
+	BcryptCost:        12,
+}
+
+// Hasher hashes passwords with the configured algorithm and verifies hashes
+// made with any supported algorithm, so stored hashes can be upgraded one
+// login at a time.
+type Hasher struct {
+	Params Params
+}
+
+func NewHasher(params Params) (*Hasher, error) {
+	switch params.Algorithm {
+	case AlgorithmArgon2id:
+		if params.Argon2Memory == 0 || params.Argon2Iterations == 0 || params.Argon2Parallelism == 0 {
+			return nil, errors.New("argon2id memory, iterations and parallelism must be positive")
+		}
+	case AlgorithmBcrypt:
+		if params.BcryptCost < bcrypt.MinCost || params.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+	return &Hasher{Params: params}, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.Params.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Params.BcryptCost)
+		return string(hash), err
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	p := h.Params
+	key := argon2.IDKey([]byte(password), salt, p.Argon2Iterations, p.Argon2Memory, p.Argon2Parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Argon2Memory, p.Argon2Iterations, p.Argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify returns ErrMismatch when password does not match hash.
+func (h *Hasher) Verify(hash string, password string) error {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return err
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other
+// cost parameters than the configured ones.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	if h.Params.Algorithm == AlgorithmBcrypt {
+		if !isBcrypt(hash) {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.Params.BcryptCost
+	}
+
+	params, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Argon2Memory != h.Params.Argon2Memory ||
+		params.Argon2Iterations != h.Params.Argon2Iterations ||
+		params.Argon2Parallelism != h.Params.Argon2Parallelism
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func decodeArgon2id(hash string) (Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+	if parts[1] != AlgorithmArgon2id {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	params := Params{Algorithm: AlgorithmArgon2id}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Iterations, &params.Argon2Parallelism)
+	if err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	return params, salt, key, nil
+}
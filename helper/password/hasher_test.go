@@ -0,0 +1,158 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keep the tests fast; they are far below DefaultParams.
+var testArgon2Params = Params{
+	Algorithm:         AlgorithmArgon2id,
+	Argon2Memory:      1024,
+	Argon2Iterations:  1,
+	Argon2Parallelism: 1,
+}
+
+var testBcryptParams = Params{
+	Algorithm:  AlgorithmBcrypt,
+	BcryptCost: bcrypt.MinCost,
+}
+
+func newTestHasher(t *testing.T, params Params) *Hasher {
+	t.Helper()
+	hasher, err := NewHasher(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hasher
+}
+
+func TestHashVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		prefix string
+	}{
+		{"argon2id", testArgon2Params, "$argon2id$"},
+		{"bcrypt", testBcryptParams, "$2a$"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hasher := newTestHasher(t, test.params)
+			hash, err := hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hash, test.prefix) {
+				t.Errorf("hash %q does not start with %q", hash, test.prefix)
+			}
+			if err := hasher.Verify(hash, "correct horse battery staple"); err != nil {
+				t.Errorf("Verify with the right password: %v", err)
+			}
+			if err := hasher.Verify(hash, "wrong horse battery staple"); !errors.Is(err, ErrMismatch) {
+				t.Errorf("Verify with a wrong password = %v, want ErrMismatch", err)
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Error("NeedsRehash reported a fresh hash")
+			}
+		})
+	}
+}
+
+func TestVerifyMalformedHash(t *testing.T) {
+	hasher := newTestHasher(t, testArgon2Params)
+	tests := []struct {
+		name string
+		hash string
+		err  error
+	}{
+		{"empty", "", ErrUnknownHash},
+		{"plain text", "hunter2", ErrUnknownHash},
+		{"other algorithm", "$scrypt$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", ErrUnknownHash},
+		{"missing part", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", ErrUnknownHash},
+		{"wrong version", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", ErrInvalidHash},
+		{"bad parameters", "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5", ErrInvalidHash},
+		{"bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5", ErrInvalidHash},
+		{"empty key", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$", ErrInvalidHash},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := hasher.Verify(test.hash, "password")
+			if !errors.Is(err, test.err) {
+				t.Errorf("Verify = %v, want %v", err, test.err)
+			}
+			if !hasher.NeedsRehash(test.hash) {
+				t.Error("NeedsRehash accepted a malformed hash")
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash, err := newTestHasher(t, testBcryptParams).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Hash, err := newTestHasher(t, testArgon2Params).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strongerArgon2 := testArgon2Params
+	strongerArgon2.Argon2Iterations = 2
+	strongerBcrypt := testBcryptParams
+	strongerBcrypt.BcryptCost = bcrypt.MinCost + 1
+
+	tests := []struct {
+		name   string
+		params Params
+		hash   string
+		rehash bool
+	}{
+		{"bcrypt hash under argon2id", testArgon2Params, bcryptHash, true},
+		{"argon2id hash under bcrypt", testBcryptParams, argon2Hash, true},
+		{"argon2id hash with old parameters", strongerArgon2, argon2Hash, true},
+		{"bcrypt hash with old cost", strongerBcrypt, bcryptHash, true},
+		{"current argon2id hash", testArgon2Params, argon2Hash, false},
+		{"current bcrypt hash", testBcryptParams, bcryptHash, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := newTestHasher(t, test.params).NeedsRehash(test.hash); got != test.rehash {
+				t.Errorf("NeedsRehash = %t, want %t", got, test.rehash)
+			}
+		})
+	}
+}
+
+// TestBcryptUpgrade follows a stored bcrypt hash through the login upgrade:
+// it still verifies under argon2id, is flagged for rehashing, and the new
+// hash verifies and is current.
+func TestBcryptUpgrade(t *testing.T) {
+	bcryptHash, err := newTestHasher(t, testBcryptParams).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hasher := newTestHasher(t, testArgon2Params)
+	if err := hasher.Verify(bcryptHash, "password"); err != nil {
+		t.Fatalf("Verify bcrypt hash under argon2id: %v", err)
+	}
+	if !hasher.NeedsRehash(bcryptHash) {
+		t.Fatal("NeedsRehash did not flag the bcrypt hash")
+	}
+
+	upgraded, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hasher.Verify(upgraded, "password"); err != nil {
+		t.Errorf("Verify upgraded hash: %v", err)
+	}
+	if hasher.NeedsRehash(upgraded) {
+		t.Error("NeedsRehash flagged the upgraded hash")
+	}
+}
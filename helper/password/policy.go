@@ -0,0 +1,86 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// breachedList is a small list of the most common leaked passwords, used
+// when no larger list is configured.
+//
+//go:embed breached.txt
+var breachedList string
+
+var (
+	ErrBreached      = errors.New("password appears in a list of breached passwords")
+	ErrContainsEmail = errors.New("password must not contain the email address")
+)
+
+// Policy decides which passwords users may choose.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	breached  map[string]bool
+}
+
+// NewPolicy builds a policy checking against the breached passwords in
+// breachedListPath, one per line, or the built-in list if the path is empty.
+func NewPolicy(minLength int, maxLength int, breachedListPath string) (*Policy, error) {
+	if minLength < 1 || maxLength < minLength {
+		return nil, fmt.Errorf("invalid password length limits %d to %d", minLength, maxLength)
+	}
+
+	var list io.Reader = strings.NewReader(breachedList)
+	if breachedListPath != "" {
+		file, err := os.Open(breachedListPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		list = file
+	}
+
+	breached := map[string]bool{}
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			breached[strings.ToLower(line)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Policy{MinLength: minLength, MaxLength: maxLength, breached: breached}, nil
+}
+
+// Check returns why password may not be used by the account with email, or
+// nil if it may.
+func (p *Policy) Check(password string, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if length > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters", p.MaxLength)
+	}
+
+	lower := strings.ToLower(password)
+	if p.breached[lower] {
+		return ErrBreached
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, _, _ := strings.Cut(email, "@")
+	if email != "" && (strings.Contains(lower, email) || (len(local) >= 3 && strings.Contains(lower, local))) {
+		return ErrContainsEmail
+	}
+	return nil
+}
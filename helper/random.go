@@ -0,0 +1,12 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+func RandomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,300 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testIssuer = "https://library.example"
+
+func writePEM(t *testing.T, dir string, kid string, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeEd25519Key(t *testing.T, dir string, kid string, private bool) ed25519.PrivateKey {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if private {
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writePEM(t, dir, kid, "PRIVATE KEY", der)
+	} else {
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writePEM(t, dir, kid, "PUBLIC KEY", der)
+	}
+	return privateKey
+}
+
+func writeRSAKey(t *testing.T, dir string, kid string, bits int) *rsa.PrivateKey {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(privateKey))
+	return privateKey
+}
+
+// useKeySet configures ks for the duration of the test.
+func useKeySet(t *testing.T, ks *KeySet) {
+	t.Helper()
+	previousKeySet, previousIssuer := keySet, issuer
+	Configure(ks, testIssuer)
+	t.Cleanup(func() { Configure(previousKeySet, previousIssuer) })
+}
+
+func TestLoadKeySet(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, dir string)
+		signingKid string
+		wantErr    string
+		wantKids   []string
+	}{
+		{
+			name: "signing and retired keys",
+			setup: func(t *testing.T, dir string) {
+				writeEd25519Key(t, dir, "2026-01", true)
+				writeEd25519Key(t, dir, "2025-07", false)
+				writeRSAKey(t, dir, "2025-01", 2048)
+			},
+			signingKid: "2026-01",
+			wantKids:   []string{"2025-01", "2025-07", "2026-01"},
+		},
+		{
+			name: "missing signing key",
+			setup: func(t *testing.T, dir string) {
+				writeEd25519Key(t, dir, "2025-07", true)
+			},
+			signingKid: "2026-01",
+			wantErr:    `signing key "2026-01" not found`,
+		},
+		{
+			name: "signing key without private half",
+			setup: func(t *testing.T, dir string) {
+				writeEd25519Key(t, dir, "2026-01", false)
+			},
+			signingKid: "2026-01",
+			wantErr:    "has no private key",
+		},
+		{
+			name: "short RSA key",
+			setup: func(t *testing.T, dir string) {
+				writeRSAKey(t, dir, "weak", 1024)
+			},
+			signingKid: "weak",
+			wantErr:    "at least 2048 bits",
+		},
+		{
+			name: "not PEM",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "junk.pem"), []byte("not a key"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			signingKid: "junk",
+			wantErr:    "no PEM block found",
+		},
+		{
+			name: "unsupported block",
+			setup: func(t *testing.T, dir string) {
+				writePEM(t, dir, "cert", "CERTIFICATE", []byte{1, 2, 3})
+			},
+			signingKid: "cert",
+			wantErr:    "unsupported PEM block",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			test.setup(t, dir)
+
+			ks, err := LoadKeySet(dir, test.signingKid)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("LoadKeySet error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ks.Signing.Id != test.signingKid {
+				t.Errorf("signing kid = %q, want %q", ks.Signing.Id, test.signingKid)
+			}
+			if len(ks.Keys) != len(test.wantKids) {
+				t.Errorf("loaded %d keys, want %d", len(ks.Keys), len(test.wantKids))
+			}
+			for _, kid := range test.wantKids {
+				if ks.Keys[kid] == nil {
+					t.Errorf("key %q not loaded", kid)
+				}
+			}
+		})
+	}
+}
+
+// TestKeyRotation signs a token, rotates to a new key while keeping the old
+// one's public half, and checks the old token still validates until its key
+// is dropped.
+func TestKeyRotation(t *testing.T) {
+	oldDir := t.TempDir()
+	writeEd25519Key(t, oldDir, "2025-07", true)
+	oldKeys, err := LoadKeySet(oldDir, "2025-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, oldKeys)
+	oldToken, err := GenerateJwtToken("42", "member", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotatedDir := t.TempDir()
+	writeEd25519Key(t, rotatedDir, "2026-01", true)
+	der, err := x509.MarshalPKIXPublicKey(oldKeys.Keys["2025-07"].PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, rotatedDir, "2025-07", "PUBLIC KEY", der)
+	rotatedKeys, err := LoadKeySet(rotatedDir, "2026-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, rotatedKeys)
+
+	validated, err := ValidateJwtToken(oldToken)
+	if err != nil {
+		t.Fatalf("token signed with the retired key: %v", err)
+	}
+	if validated.AuthId != "42" || validated.Role != "member" || validated.SessionVersion != 3 {
+		t.Errorf("validated token = %+v", validated)
+	}
+
+	newToken, err := GenerateJwtToken("42", "member", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateJwtToken(newToken); err != nil {
+		t.Errorf("token signed with the new key: %v", err)
+	}
+
+	droppedDir := t.TempDir()
+	writeEd25519Key(t, droppedDir, "2026-01", true)
+	droppedKeys, err := LoadKeySet(droppedDir, "2026-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, droppedKeys)
+	_, err = ValidateJwtToken(oldToken)
+	if err == nil || !strings.Contains(err.Error(), `unknown key id "2025-07"`) {
+		t.Errorf("token signed with a dropped key: error = %v", err)
+	}
+}
+
+func TestValidateJwtTokenRejects(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "current", true)
+	ks, err := LoadKeySet(dir, "current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, ks)
+	valid, err := GenerateJwtToken("42", "member", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+
+	otherDir := t.TempDir()
+	writeEd25519Key(t, otherDir, "current", true)
+	other, err := LoadKeySet(otherDir, "current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, other)
+	forged, err := GenerateJwtToken("1", "admin", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeySet(t, ks)
+
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT","kid":"current"}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"signed by another key with the same kid", forged},
+		{"tampered payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","role":"admin"}`)) + "." + parts[2]},
+		{"alg none", noneHeader + "." + parts[1] + "."},
+		{"garbage", "not.a.token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ValidateJwtToken(test.token); err == nil {
+				t.Error("ValidateJwtToken accepted the token")
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	dir := t.TempDir()
+	edKey := writeEd25519Key(t, dir, "b-ed25519", true)
+	rsaKey := writeRSAKey(t, dir, "a-rsa", 2048)
+	writeEd25519Key(t, dir, "c-retired", false)
+	ks, err := LoadKeySet(dir, "b-ed25519")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := ks.JWKS()
+	if len(jwks.Keys) != 3 {
+		t.Fatalf("JWKS has %d keys, want 3", len(jwks.Keys))
+	}
+	for i, kid := range []string{"a-rsa", "b-ed25519", "c-retired"} {
+		if jwks.Keys[i].Kid != kid {
+			t.Errorf("key %d kid = %q, want %q", i, jwks.Keys[i].Kid, kid)
+		}
+		if jwks.Keys[i].Use != "sig" {
+			t.Errorf("key %q use = %q, want sig", kid, jwks.Keys[i].Use)
+		}
+	}
+
+	rsaJWK := jwks.Keys[0]
+	if rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" || rsaJWK.E != "AQAB" {
+		t.Errorf("RSA JWK = %+v", rsaJWK)
+	}
+	if rsaJWK.N != base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()) {
+		t.Error("RSA JWK modulus does not match the key")
+	}
+
+	edJWK := jwks.Keys[1]
+	if edJWK.Kty != "OKP" || edJWK.Crv != "Ed25519" || edJWK.Alg != "EdDSA" || edJWK.N != "" {
+		t.Errorf("Ed25519 JWK = %+v", edJWK)
+	}
+	if edJWK.X != base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey)) {
+		t.Error("Ed25519 JWK x does not match the key")
+	}
+}
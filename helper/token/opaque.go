@@ -0,0 +1,22 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"kukuh/go-gin-library-project/helper"
+)
+
+// NewOpaqueToken returns a random token to send to the user and the hash to
+// store in its place. The hash is keyed with TOKEN_Key, so tokens
+// cannot be forged without it and a leaked table cannot be replayed.
+func NewOpaqueToken() (string, string) {
+	plain := helper.RandomHex(32)
+	return plain, HashOpaqueToken(plain)
+}
+
+func HashOpaqueToken(plain string) string {
+	mac := hmac.New(sha256.New, []byte(TOKEN_Key))
+	mac.Write([]byte(plain))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -4,5 +4,7 @@ import "time"
 
 type Token struct {
 	AuthId         string    `json:"auth_id"`
+	Role           string    `json:"role"`
+	SessionVersion int       `json:"session_version"`
 	ExpirationTime time.Time `json:"expiration_time"`
 }
@@ -1,68 +1,108 @@
 package token
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"kukuh/go-gin-library-project/helper"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 var (
-	TOKEN_Key        = os.Getenv("JWT_SECRET")
+	// TOKEN_Key keys the hashes of opaque tokens, API keys and recovery
+	// codes; JWTs are signed with the configured key set instead. It is set
+	// at startup from TOKEN_HASH_KEY.
+	TOKEN_Key        string
 	TOKEN_Expiration = 24 * time.Hour
 )
 
-func GenerateJwtToken(authId string) (string, error) {
-	payload := Token{
-		AuthId:         authId,
-		ExpirationTime: time.Now().Add(TOKEN_Expiration),
-	}
-	claims := jwt.MapClaims{
-		"payload": payload,
+var (
+	keySet *KeySet
+	issuer string
+)
+
+// Configure sets the keys tokens are signed and verified with and the issuer
+// put in and required of their iss claim. It must be called before tokens
+// are generated or validated.
+func Configure(ks *KeySet, iss string) {
+	keySet = ks
+	issuer = iss
+}
+
+// PublicKeys returns the JWKS document other services verify our tokens
+// with.
+func PublicKeys() JWKS {
+	return keySet.JWKS()
+}
+
+type claims struct {
+	Role           string `json:"role"`
+	SessionVersion int    `json:"session_version"`
+	jwt.RegisteredClaims
+}
+
+func GenerateJwtToken(authId string, role string, sessionVersion int) (string, error) {
+	if keySet == nil {
+		return "", errors.New("token keys are not configured")
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString([]byte(TOKEN_Key))
-	if err != nil {
-		return "", err
+	now := time.Now()
+	tokenClaims := claims{
+		Role:           role,
+		SessionVersion: sessionVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   authId,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(TOKEN_Expiration)),
+			ID:        helper.RandomHex(16),
+		},
 	}
-	return tokenStr, nil
+	signing := keySet.Signing
+	token := jwt.NewWithClaims(signing.Method, tokenClaims)
+	token.Header["kid"] = signing.Id
+	return token.SignedString(signing.PrivateKey)
 }
 
+// ValidateJwtToken verifies the token with the key named by its kid header
+// and checks the registered claims.
 func ValidateJwtToken(tokenString string) (*Token, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+	if keySet == nil {
+		return nil, errors.New("token keys are not configured")
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+
+	tokenClaims := &claims{}
+	_, err := jwt.ParseWithClaims(tokenString, tokenClaims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keySet.Keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if t.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-
-		return []byte(TOKEN_Key), nil
-	})
-
+		return key.PublicKey, nil
+	}, options...)
 	if err != nil {
 		return nil, err
 	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		payloadInterface := claims["payload"]
-
-		payloadToken := Token{}
-
-		payloadByte, err := json.Marshal(payloadInterface)
-		if err != nil {
-			return nil, err
-		}
-		err = json.Unmarshal(payloadByte, &payloadToken)
-		if err != nil {
-			return nil, err
-		}
-		now := time.Now()
-		if now.After(payloadToken.ExpirationTime) {
-			return nil, errors.New("Token Expired")
-		}
-		return &payloadToken, nil
-	} else {
-		return nil, errors.New("Unauthorized")
+	if tokenClaims.Subject == "" {
+		return nil, errors.New("token has no subject")
 	}
+
+	return &Token{
+		AuthId:         tokenClaims.Subject,
+		Role:           tokenClaims.Role,
+		SessionVersion: tokenClaims.SessionVersion,
+		ExpirationTime: tokenClaims.ExpiresAt.Time,
+	}, nil
 }
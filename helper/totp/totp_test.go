@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 appendix B, "12345678901234567890",
+// base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfcVectors are the SHA-1 test vectors of RFC 6238 appendix B, truncated to
+// the six digits this package produces.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, vector := range rfcVectors {
+		code, err := Code(rfcSecret, Step(time.Unix(vector.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", vector.unix, err)
+		}
+		if code != vector.code {
+			t.Errorf("Code at %d = %s, want %s", vector.unix, code, vector.code)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	code, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "287082" {
+		t.Errorf("Code = %s, want 287082", code)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	_, err := Code("not base32!", 1)
+	if err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, vector := range rfcVectors {
+		at := time.Unix(vector.unix, 0)
+		step, ok := Validate(rfcSecret, vector.code, at, 0)
+		if !ok || step != Step(at) {
+			t.Errorf("Validate at %d = %d, %t, want %d, true", vector.unix, step, ok, Step(at))
+		}
+	}
+
+	tests := []struct {
+		name   string
+		code   string
+		offset time.Duration
+		skew   int64
+		ok     bool
+	}{
+		{"previous step within skew", "081804", Period * time.Second, 1, true},
+		{"next step within skew", "081804", -Period * time.Second, 1, true},
+		{"previous step without skew", "081804", Period * time.Second, 0, false},
+		{"beyond skew", "081804", 2 * Period * time.Second, 1, false},
+		{"wrong code", "123456", 0, 1, false},
+		{"short code", "81804", 0, 1, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			at := time.Unix(1111111109, 0).Add(test.offset)
+			step, ok := Validate(rfcSecret, test.code, at, test.skew)
+			if ok != test.ok {
+				t.Fatalf("Validate ok = %t, want %t", ok, test.ok)
+			}
+			if ok && step != Step(time.Unix(1111111109, 0)) {
+				t.Errorf("Validate step = %d, want %d", step, Step(time.Unix(1111111109, 0)))
+			}
+		})
+	}
+}
@@ -1,14 +1,29 @@
 package main
 
 import (
+	"context"
 	"kukuh/go-gin-library-project/app/controller"
+	"kukuh/go-gin-library-project/app/event"
+	"kukuh/go-gin-library-project/app/job"
+	"kukuh/go-gin-library-project/app/model"
 	"kukuh/go-gin-library-project/app/repository"
+	"kukuh/go-gin-library-project/app/scheduler"
 	"kukuh/go-gin-library-project/app/service"
 	"kukuh/go-gin-library-project/database"
+	"kukuh/go-gin-library-project/helper"
+	"kukuh/go-gin-library-project/helper/mail"
+	"kukuh/go-gin-library-project/helper/oidc"
+	"kukuh/go-gin-library-project/helper/password"
+	"kukuh/go-gin-library-project/helper/storage"
 	"kukuh/go-gin-library-project/helper/token"
 	"kukuh/go-gin-library-project/response"
 	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -24,22 +39,191 @@ func main() {
 	// Initialize validator
 	validate := validator.New()
 
+	// Initialize storage
+	blobStorage := storage.NewLocalStorage(os.Getenv("STORAGE_PATH"))
+
+	// Initialize mail sender
+	mailFrom := getEnv("MAIL_FROM", "library@localhost")
+	var mailSender mail.Sender
+	mailDriver := os.Getenv("MAIL_DRIVER")
+	if mailDriver == "" {
+		// Mail that is only logged never reaches users, so outside
+		// development the driver has to be chosen explicitly.
+		if getEnv("ENVIRONMENT", "development") != "development" {
+			log.Fatalf("MAIL_DRIVER must be set outside development")
+		}
+		log.Printf("MAIL_DRIVER is not set, logging mail instead of sending it")
+		mailDriver = "log"
+	}
+	switch mailDriver {
+	case "smtp":
+		mailSender = mail.NewSMTPSender(os.Getenv("SMTP_HOST"), getEnv("SMTP_PORT", "587"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), mailFrom)
+	case "file":
+		mailSender = mail.NewFileSender(os.Getenv("MAIL_PATH"), mailFrom)
+	case "log":
+		mailSender = mail.NewLogSender(mailFrom)
+	default:
+		log.Fatalf("Unknown MAIL_DRIVER %q", mailDriver)
+	}
+
+	// Initialize token hashing. JWT_SECRET is the key's former name and is
+	// still read so hashes stored before the rename keep matching.
+	tokenHashKey := getEnv("TOKEN_HASH_KEY", os.Getenv("JWT_SECRET"))
+	if len(tokenHashKey) < 32 {
+		log.Fatalf("TOKEN_HASH_KEY must be set to at least 32 characters")
+	}
+	token.TOKEN_Key = tokenHashKey
+
+	// Initialize JWT signing keys
+	var keySet *token.KeySet
+	if keysDir := os.Getenv("JWT_KEYS_DIR"); keysDir != "" {
+		keySet, err = token.LoadKeySet(keysDir, os.Getenv("JWT_SIGNING_KID"))
+	} else {
+		// An ephemeral key signs everyone out on restart and is not shared
+		// between replicas, so it is only good enough for development.
+		if getEnv("ENVIRONMENT", "development") != "development" {
+			log.Fatalf("JWT_KEYS_DIR must be set outside development")
+		}
+		log.Printf("JWT_KEYS_DIR is not set, signing tokens with an ephemeral key")
+		keySet, err = token.NewEphemeralKeySet()
+	}
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	token.Configure(keySet, getEnv("JWT_ISSUER", getEnv("APP_URL", "http://localhost:3000")))
+
+	// Initialize password hashing and policy
+	passwordHasher, err := password.NewHasher(password.Params{
+		Algorithm:         getEnv("PASSWORD_HASHER", password.DefaultParams.Algorithm),
+		Argon2Memory:      uint32(getEnvInt("ARGON2_MEMORY_KIB", int(password.DefaultParams.Argon2Memory))),
+		Argon2Iterations:  uint32(getEnvInt("ARGON2_ITERATIONS", int(password.DefaultParams.Argon2Iterations))),
+		Argon2Parallelism: uint8(getEnvInt("ARGON2_PARALLELISM", int(password.DefaultParams.Argon2Parallelism))),
+		BcryptCost:        getEnvInt("BCRYPT_COST", password.DefaultParams.BcryptCost),
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure password hashing: %v", err)
+	}
+	passwordPolicy, err := password.NewPolicy(getEnvInt("PASSWORD_MIN_LENGTH", 8), getEnvInt("PASSWORD_MAX_LENGTH", 128), os.Getenv("PASSWORD_BREACHED_LIST"))
+	if err != nil {
+		log.Fatalf("Failed to load password policy: %v", err)
+	}
+
 	// Initialize repositories
 	userRepository := repository.NewUserRepository()
+	userTokenRepository := repository.NewUserTokenRepository()
+	loginThrottleRepository := repository.NewLoginThrottleRepository()
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository()
+	apiKeyRepository := repository.NewApiKeyRepository()
+	userIdentityRepository := repository.NewUserIdentityRepository()
+	oidcLoginRepository := repository.NewOidcLoginRepository()
 	bookRepository := repository.NewBookRepository()
+	authorRepository := repository.NewAuthorRepository()
+	publisherRepository := repository.NewPublisherRepository()
+	subjectRepository := repository.NewSubjectRepository()
 	borrowingRepository := repository.NewBorrowingRepository()
+	auditLogRepository := repository.NewAuditLogRepository()
+	fineRepository := repository.NewFineRepository()
+	circulationPolicyRepository := repository.NewCirculationPolicyRepository()
+	jobRunRepository := repository.NewJobRunRepository()
+	notificationRepository := repository.NewNotificationRepository()
+	webhookRepository := repository.NewWebhookRepository()
+	outboxRepository := repository.NewOutboxRepository()
 
 	// Initialize services
-	userService := service.NewUserService(userRepository, db, validate)
-	bookService := service.NewBookService(bookRepository, db, validate)
-	borrowingService := service.NewBorrowingService(borrowingRepository, bookRepository, db, validate)
+	bookService := service.NewBookService(bookRepository, authorRepository, publisherRepository, subjectRepository, borrowingRepository, auditLogRepository, outboxRepository, blobStorage, db, validate)
+	authorService := service.NewAuthorService(authorRepository, auditLogRepository, db, validate)
+	publisherService := service.NewPublisherService(publisherRepository, auditLogRepository, db, validate)
+	subjectService := service.NewSubjectService(subjectRepository, auditLogRepository, db, validate)
+	circulationPolicyService := service.NewCirculationPolicyService(circulationPolicyRepository, borrowingRepository, fineRepository, db, validate)
+	notificationService := service.NewNotificationService(notificationRepository, borrowingRepository, bookRepository, userRepository, mailSender, db, validate)
+	twoFactorService := service.NewTwoFactorService(userRepository, recoveryCodeRepository, loginThrottleRepository, auditLogRepository, passwordHasher, getEnv("TOTP_ISSUER", "Library"), getEnvList("TWO_FACTOR_REQUIRED_ROLES"), db, validate)
+	userService := service.NewUserService(userRepository, userTokenRepository, loginThrottleRepository, userIdentityRepository, borrowingRepository, fineRepository, auditLogRepository, outboxRepository, notificationService, twoFactorService, passwordHasher, passwordPolicy, getEnv("APP_URL", "http://localhost:3000"), db, validate)
+	borrowingService := service.NewBorrowingService(borrowingRepository, bookRepository, userRepository, fineRepository, auditLogRepository, outboxRepository, circulationPolicyService, db, validate)
+	auditLogService := service.NewAuditLogService(auditLogRepository, db)
+	webhookService := service.NewWebhookService(webhookRepository, db, validate)
+	apiKeyService := service.NewApiKeyService(apiKeyRepository, auditLogRepository, db, validate)
+
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
+		created, err := userService.BootstrapAdmin(context.Background(), getEnv("ADMIN_NAME", "Administrator"), adminEmail, os.Getenv("ADMIN_PASSWORD"))
+		if err != nil {
+			log.Fatalf("Failed to create admin account: %v", err)
+		}
+		if created {
+			log.Printf("Created admin account %s", adminEmail)
+		}
+	}
+
+	// Initialize event dispatcher
+	dispatcher := event.NewDispatcher(outboxRepository, db)
+	dispatcher.Subscribe("notifications", notificationService)
+	dispatcher.Subscribe("webhooks", webhookService)
+	go dispatcher.Start(context.Background(), time.Second)
+
+	// Initialize jobs
+	jobScheduler := scheduler.NewScheduler(jobRunRepository, db)
+	err = jobScheduler.Register("purge", getEnv("PURGE_JOB_SCHEDULE", "0 3 * * *"), job.NewPurgeJob(bookService, userService))
+	if err != nil {
+		log.Fatalf("Failed to register purge job: %v", err)
+	}
+	err = jobScheduler.Register("overdue", getEnv("OVERDUE_JOB_SCHEDULE", "*/15 * * * *"), job.NewOverdueJob(borrowingService))
+	if err != nil {
+		log.Fatalf("Failed to register overdue job: %v", err)
+	}
+	err = jobScheduler.Register("notifications", getEnv("NOTIFICATION_JOB_SCHEDULE", "0 * * * *"), job.NewNotificationJob(notificationService))
+	if err != nil {
+		log.Fatalf("Failed to register notification job: %v", err)
+	}
+	err = jobScheduler.Register("webhooks", getEnv("WEBHOOK_JOB_SCHEDULE", "* * * * *"), job.NewWebhookJob(webhookService))
+	if err != nil {
+		log.Fatalf("Failed to register webhook job: %v", err)
+	}
+	go jobScheduler.Start(context.Background())
+	jobService := service.NewJobService(jobScheduler, jobRunRepository, db)
 
 	// Initialize controllers
 	userController := controller.NewUserController(userService)
+	twoFactorController := controller.NewTwoFactorController(twoFactorService)
+	jwksController := controller.NewJwksController()
+	apiKeyController := controller.NewApiKeyController(apiKeyService)
+
+	// OIDC login is only offered when an identity provider is configured
+	var oidcController controller.OidcController
+	if oidcIssuer := os.Getenv("OIDC_ISSUER"); oidcIssuer != "" {
+		oidcProvider := oidc.NewProvider(oidc.Config{
+			Issuer:       oidcIssuer,
+			ClientId:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectUrl:  getEnv("OIDC_REDIRECT_URL", getEnv("APP_URL", "http://localhost:3000")+"/api/oidc/callback"),
+			Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+		}, &http.Client{Timeout: 10 * time.Second})
+		oidcService := service.NewOidcService(oidcProvider, oidcLoginRepository, userService, db, validate)
+		oidcController = controller.NewOidcController(oidcService)
+	}
 	bookController := controller.NewBookController(bookService)
+	authorController := controller.NewAuthorController(authorService)
+	publisherController := controller.NewPublisherController(publisherService)
+	subjectController := controller.NewSubjectController(subjectService)
 	borrowingController := controller.NewBorrowingController(borrowingService)
+	auditLogController := controller.NewAuditLogController(auditLogService)
+	circulationPolicyController := controller.NewCirculationPolicyController(circulationPolicyService)
+	jobController := controller.NewJobController(jobService)
+	notificationController := controller.NewNotificationController(notificationService)
+	webhookController := controller.NewWebhookController(webhookService)
 
 	router := gin.Default()
+	router.MaxMultipartMemory = service.MaxCoverSize
+	// The client IP keys the login throttle, so X-Forwarded-For is only
+	// believed from the proxies listed here; by default it is ignored.
+	var trustedProxies []string
+	if proxies := getEnvList("TRUSTED_PROXIES"); len(proxies) > 0 {
+		trustedProxies = proxies
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
+	router.Use(RequestId())
+
+	router.GET("/.well-known/jwks.json", jwksController.Find)
 
 	// API Grouping
 	api := router.Group("/api")
@@ -47,22 +231,111 @@ func main() {
 
 		api.POST("/register", userController.Register)
 		api.POST("/login", userController.Login)
+		api.POST("/login/2fa", userController.LoginTwoFactor)
+		api.GET("/email/verify", userController.VerifyEmail)
+		api.POST("/email/resend", userController.ResendVerification)
+		api.GET("/email/change/confirm", userController.ConfirmEmailChange)
+		api.POST("/password/forgot", userController.ForgotPassword)
+		api.POST("/password/reset", userController.ResetPassword)
+		if oidcController != nil {
+			api.GET("/oidc/login", oidcController.Login)
+			api.GET("/oidc/callback", oidcController.Callback)
+		}
 
-		api.POST("/book", bookController.Create)
-		api.PUT("/book/:id", bookController.Update)
 		api.GET("/book/:id", bookController.Find)
-		api.DELETE("/book/:id", bookController.Delete)
 		api.GET("/book", bookController.FindAll)
+		api.GET("/book/:id/cover", bookController.FindCover)
+		api.GET("/author/:id", authorController.Find)
+		api.GET("/author", authorController.FindAll)
+		api.GET("/publisher/:id", publisherController.Find)
+		api.GET("/publisher", publisherController.FindAll)
+		api.GET("/subject/:id", subjectController.Find)
+		api.GET("/subject", subjectController.FindAll)
+
+		staff := api.Group("")
+		staff.Use(CheckAuthOrApiKey(userService, apiKeyService), CheckRoleOrScope(model.ScopeCatalogWrite, model.RoleAdmin, model.RoleLibrarian), CheckTwoFactor(twoFactorService))
+		{
+			staff.POST("/book", bookController.Create)
+			staff.PUT("/book/:id", bookController.Update)
+			staff.PATCH("/book/:id", bookController.Patch)
+			staff.DELETE("/book/:id", bookController.Delete)
+			staff.POST("/book/:id/cover", bookController.UploadCover)
+
+			staff.POST("/author", authorController.Create)
+			staff.PUT("/author/:id", authorController.Update)
+			staff.DELETE("/author/:id", authorController.Delete)
+
+			staff.POST("/publisher", publisherController.Create)
+			staff.PUT("/publisher/:id", publisherController.Update)
+			staff.DELETE("/publisher/:id", publisherController.Delete)
+
+			staff.POST("/subject", subjectController.Create)
+			staff.PUT("/subject/:id", subjectController.Update)
+			staff.DELETE("/subject/:id", subjectController.Delete)
+		}
 
 		auth := api.Group("/auth")
-		auth.Use(CheckAuth())
+		auth.Use(CheckAuth(userService))
 		{
+			auth.GET("/me", userController.FindOwn)
 			auth.PUT("/users", userController.UpdateUserOwn)
+			auth.PUT("/users/password", userController.ChangePassword)
+			auth.POST("/users/email", userController.ChangeEmail)
+			auth.PATCH("/users", userController.PatchUserOwn)
 			auth.DELETE("/users", userController.DeleteUser)
+			auth.POST("/users/2fa/enroll", twoFactorController.Enroll)
+			auth.POST("/users/2fa/confirm", twoFactorController.Confirm)
+			auth.POST("/users/2fa/disable", twoFactorController.Disable)
+			auth.POST("/users/2fa/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+			auth.GET("/users/notifications", notificationController.FindPreference)
+			auth.PUT("/users/notifications", notificationController.UpdatePreference)
 			auth.POST("/borrowing", borrowingController.Create)
 			auth.POST("/borrowing/return/:id", borrowingController.Return)
+			auth.POST("/borrowing/renew/:id", borrowingController.Renew)
 			auth.GET("/borrowing/:id", borrowingController.Find)
-			auth.GET("/borrowing", borrowingController.FindAll)
+			auth.GET("/borrowing", CheckRole(model.RoleAdmin, model.RoleLibrarian), CheckTwoFactor(twoFactorService), borrowingController.FindAll)
+			auth.GET("/me/loans", borrowingController.FindOwn)
+		}
+
+		// Admin routes are open to API keys route by route: staffOr lets a key
+		// with the scope through, staffOnly does not.
+		admin := api.Group("/admin")
+		admin.Use(CheckAuthOrApiKey(userService, apiKeyService), CheckTwoFactor(twoFactorService))
+		{
+			staffOnly := CheckRole(model.RoleAdmin, model.RoleLibrarian)
+			staffOr := func(scope string) gin.HandlerFunc {
+				return CheckRoleOrScope(scope, model.RoleAdmin, model.RoleLibrarian)
+			}
+
+			admin.GET("/book/deleted", staffOr(model.ScopeCatalogWrite), bookController.FindDeleted)
+			admin.POST("/book/:id/restore", staffOr(model.ScopeCatalogWrite), bookController.Restore)
+			admin.GET("/users", staffOr(model.ScopeUsersWrite), userController.FindAll)
+			admin.POST("/users", staffOr(model.ScopeUsersWrite), userController.Create)
+			admin.GET("/users/deleted", staffOr(model.ScopeUsersWrite), userController.FindDeleted)
+			admin.GET("/users/:id", staffOr(model.ScopeUsersWrite), userController.FindById)
+			admin.PUT("/users/:id", staffOr(model.ScopeUsersWrite), userController.UpdateAccount)
+			admin.POST("/users/:id/status", staffOr(model.ScopeUsersWrite), userController.UpdateStatus)
+			admin.GET("/users/:id/loans", staffOr(model.ScopeUsersWrite), borrowingController.FindByUser)
+			admin.POST("/users/:id/restore", staffOr(model.ScopeUsersWrite), userController.Restore)
+			admin.POST("/users/:id/unlock", staffOr(model.ScopeUsersWrite), userController.Unlock)
+			admin.POST("/borrowing/:id/status", staffOr(model.ScopeLoansWrite), borrowingController.UpdateStatus)
+			admin.GET("/reports/lost", staffOr(model.ScopeReportsRead), borrowingController.FindLost)
+			admin.GET("/audit", staffOr(model.ScopeAuditRead), auditLogController.FindAll)
+			admin.GET("/policy", staffOnly, circulationPolicyController.FindAll)
+			admin.PUT("/policy", staffOnly, circulationPolicyController.Save)
+			admin.DELETE("/policy/:id", staffOnly, circulationPolicyController.Delete)
+			admin.GET("/jobs", staffOnly, jobController.FindAll)
+			admin.GET("/jobs/runs", staffOnly, jobController.FindRuns)
+			admin.POST("/jobs/:name/run", staffOnly, jobController.Trigger)
+			admin.GET("/webhooks", staffOnly, webhookController.FindAll)
+			admin.POST("/webhooks", staffOnly, webhookController.Create)
+			admin.PUT("/webhooks/:id", staffOnly, webhookController.Update)
+			admin.DELETE("/webhooks/:id", staffOnly, webhookController.Delete)
+			admin.GET("/webhooks/:id/deliveries", staffOnly, webhookController.FindDeliveries)
+			admin.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", staffOnly, webhookController.Redeliver)
+			admin.GET("/api-keys", CheckRole(model.RoleAdmin), apiKeyController.FindAll)
+			admin.POST("/api-keys", CheckRole(model.RoleAdmin), apiKeyController.Create)
+			admin.DELETE("/api-keys/:id", CheckRole(model.RoleAdmin), apiKeyController.Revoke)
 		}
 	}
 
@@ -71,7 +344,7 @@ func main() {
 	}
 }
 
-func CheckAuth() gin.HandlerFunc {
+func CheckAuth(userService service.UserService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		header := ctx.GetHeader("Authorization")
 
@@ -89,7 +362,134 @@ func CheckAuth() gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
 			return
 		}
+		userId, err := strconv.Atoi(payload.AuthId)
+		if err != nil {
+			resp := response.UnauthorizedError("invalid auth id")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		if resp := userService.ValidateSession(ctx.Request.Context(), userId, payload.SessionVersion); resp != nil {
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		role := payload.Role
+		if role == "" {
+			role = model.RoleMember
+		}
 		ctx.Set("authId", payload.AuthId)
+		ctx.Set("authRole", role)
+		ctx.Request = ctx.Request.WithContext(helper.WithAuthId(ctx.Request.Context(), payload.AuthId))
 		ctx.Next()
 	}
 }
+
+// CheckAuthOrApiKey is CheckAuth for routes machine clients may also call.
+// A bearer token with the API key prefix is checked as an API key and the
+// key is stored as "apiKey"; anything else goes through CheckAuth. API keys
+// have no user or role, so the route must also check scopes, with
+// CheckRoleOrScope.
+func CheckAuthOrApiKey(userService service.UserService, apiKeyService service.ApiKeyService) gin.HandlerFunc {
+	checkAuth := CheckAuth(userService)
+	return func(ctx *gin.Context) {
+		bearerToken, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(bearerToken, model.ApiKeyPrefix) {
+			checkAuth(ctx)
+			return
+		}
+
+		apiKey, resp := apiKeyService.Authenticate(ctx.Request.Context(), bearerToken)
+		if resp != nil {
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		ctx.Set("apiKey", apiKey)
+		ctx.Next()
+	}
+}
+
+// CheckTwoFactor blocks users whose role requires two-factor
+// authentication until they have enabled it. It must run after CheckAuth,
+// and lets API keys through.
+func CheckTwoFactor(twoFactorService service.TwoFactorService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, exists := ctx.Get("apiKey"); exists {
+			ctx.Next()
+			return
+		}
+		userId, _ := strconv.Atoi(ctx.GetString("authId"))
+		if resp := twoFactorService.CheckEnrollment(ctx.Request.Context(), userId); resp != nil {
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// CheckRoleOrScope lets users with one of roles through, and API keys that
+// have scope.
+func CheckRoleOrScope(scope string, roles ...string) gin.HandlerFunc {
+	checkRole := CheckRole(roles...)
+	return func(ctx *gin.Context) {
+		value, exists := ctx.Get("apiKey")
+		if !exists {
+			checkRole(ctx)
+			return
+		}
+		if apiKey := value.(*model.ApiKey); !apiKey.HasScope(scope) {
+			resp := response.ForbiddenError("API key lacks scope " + scope)
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func CheckRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString("authRole")
+		if !slices.Contains(roles, role) {
+			resp := response.ForbiddenError("role " + role + " is not allowed")
+			ctx.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func RequestId() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestId := ctx.GetHeader("X-Request-ID")
+		if requestId == "" || len(requestId) > 64 {
+			requestId = helper.RandomHex(16)
+		}
+		ctx.Header("X-Request-ID", requestId)
+		ctx.Request = ctx.Request.WithContext(helper.WithRequestId(ctx.Request.Context(), requestId))
+		ctx.Next()
+	}
+}
+
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	values := []string{}
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
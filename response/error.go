@@ -43,8 +43,60 @@ var (
 		Status:     false,
 		Message:    "BAD REQUEST ERROR",
 	}
+	forbiddenError = CustomError{
+		Code:       "ERR0008",
+		StatusCode: http.StatusForbidden,
+		Status:     false,
+		Message:    "FORBIDDEN",
+	}
+	preconditionFailedError = CustomError{
+		Code:       "ERR0009",
+		StatusCode: http.StatusPreconditionFailed,
+		Status:     false,
+		Message:    "PRECONDITION FAILED",
+	}
+	preconditionRequiredError = CustomError{
+		Code:       "ERR0010",
+		StatusCode: http.StatusPreconditionRequired,
+		Status:     false,
+		Message:    "PRECONDITION REQUIRED",
+	}
+	policyViolationError = CustomError{
+		Code:       "ERR0011",
+		StatusCode: http.StatusUnprocessableEntity,
+		Status:     false,
+		Message:    "CIRCULATION POLICY VIOLATION",
+	}
+	conflictError = CustomError{
+		Code:       "ERR0012",
+		StatusCode: http.StatusConflict,
+		Status:     false,
+		Message:    "CONFLICT",
+	}
+	tooManyRequestsError = CustomError{
+		Code:       "ERR0013",
+		StatusCode: http.StatusTooManyRequests,
+		Status:     false,
+		Message:    "TOO MANY REQUESTS",
+	}
+	payloadTooLargeError = CustomError{
+		Code:       "ERR0006",
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Status:     false,
+		Message:    "PAYLOAD TOO LARGE",
+	}
+	unsupportedMediaTypeError = CustomError{
+		Code:       "ERR0007",
+		StatusCode: http.StatusUnsupportedMediaType,
+		Status:     false,
+		Message:    "UNSUPPORTED MEDIA TYPE",
+	}
 )
 
+func (e *CustomError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
 func GeneralError(message ...string) *CustomError {
 	err := generalError
 	if len(message) != 0 {
@@ -84,3 +136,69 @@ func BadRequestError(message ...string) *CustomError {
 	}
 	return &err
 }
+
+func PayloadTooLargeError(message ...string) *CustomError {
+	err := payloadTooLargeError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func UnsupportedMediaTypeError(message ...string) *CustomError {
+	err := unsupportedMediaTypeError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func ForbiddenError(message ...string) *CustomError {
+	err := forbiddenError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func PreconditionFailedError(message ...string) *CustomError {
+	err := preconditionFailedError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func PreconditionRequiredError(message ...string) *CustomError {
+	err := preconditionRequiredError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func ConflictError(message ...string) *CustomError {
+	err := conflictError
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func PolicyViolationError(additionalInfo any, message ...string) *CustomError {
+	err := policyViolationError
+	err.AdditionalInfo = additionalInfo
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}
+
+func TooManyRequestsError(additionalInfo any, message ...string) *CustomError {
+	err := tooManyRequestsError
+	err.AdditionalInfo = additionalInfo
+	if len(message) != 0 {
+		err.Message = message[0]
+	}
+	return &err
+}